@@ -0,0 +1,5 @@
+DELETE FROM `M_COLOR`
+WHERE `game_id` = :game_id
+{{- if .Color }}
+  AND `color` = :color
+{{- end }};
\ No newline at end of file
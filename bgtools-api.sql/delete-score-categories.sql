@@ -0,0 +1,2 @@
+DELETE FROM `M_SCORE_CATEGORY`
+WHERE `game_id` = :game_id;
\ No newline at end of file
@@ -0,0 +1,15 @@
+SELECT
+  `bg`.`id`,
+  `bg`.`unique_name`,
+  `bg`.`title`,
+  `bg`.`min_players`,
+  `bg`.`max_players`,
+  `bg`.`playing_time`,
+  `bg`.`min_age`,
+  CAST(`bg`.`is_expansion` AS UNSIGNED) AS `is_expansion`,
+  `bg`.`expansion_base_id`,
+  `bg`.`product_url`,
+  CAST(`bg`.`bodoge_hoobby_net` AS UNSIGNED) AS `bodoge_hoobby_net`,
+  CAST(`bg`.`score_tool` AS UNSIGNED) AS `score_tool`
+FROM `M_BOARDGAME` AS `bg`
+WHERE `bg`.`id` = :game_id;
\ No newline at end of file
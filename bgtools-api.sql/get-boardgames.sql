@@ -0,0 +1,42 @@
+SELECT
+{{- if .Count }}
+  COUNT(*)
+{{- else }}
+  `bg`.`id`,
+  `bg`.`unique_name`,
+  `bg`.`title`,
+  `bg`.`min_players`,
+  `bg`.`max_players`,
+  `bg`.`playing_time`,
+  `bg`.`min_age`,
+  CAST(`bg`.`is_expansion` AS UNSIGNED) AS `is_expansion`,
+  `bg`.`expansion_base_id`,
+  `bg`.`product_url`,
+  CAST(`bg`.`bodoge_hoobby_net` AS UNSIGNED) AS `bodoge_hoobby_net`,
+  CAST(`bg`.`score_tool` AS UNSIGNED) AS `score_tool`
+{{- end }}
+FROM `M_BOARDGAME` AS `bg`
+WHERE 1 = 1
+{{- if .Players }}
+  AND `bg`.`min_players` <= :players
+  AND :players <= `bg`.`max_players`
+{{- end }}
+{{- if .Age }}
+  AND `bg`.`min_age` <= :age
+{{- end }}
+{{- if .MaxTime }}
+  AND `bg`.`playing_time` <> ''
+  AND CAST(SUBSTRING_INDEX(`bg`.`playing_time`, '-', -1) AS UNSIGNED) <= :max_time
+{{- end }}
+{{- if .IsExpansion }}
+  AND CAST(`bg`.`is_expansion` AS UNSIGNED) = :is_expansion
+{{- end }}
+{{- if .BaseId }}
+  AND `bg`.`expansion_base_id` = :base_id
+{{- end }}
+{{- if not .Count }}
+ORDER BY {{ .Sort }}{{ if .Desc }} DESC{{ end }}, `bg`.`id`
+{{- if .Limit }}
+LIMIT :limit OFFSET :offset
+{{- end }}
+{{- end }};
\ No newline at end of file
@@ -0,0 +1,5 @@
+SELECT
+  `col`.`game_id`,
+  `col`.`color`
+FROM `M_COLOR` AS `col`
+WHERE `col`.`game_id` = :game_id;
\ No newline at end of file
@@ -0,0 +1,19 @@
+SELECT
+  `bg`.`id`,
+  `bg`.`unique_name`,
+  `bg`.`title`,
+  `bg`.`min_players`,
+  `bg`.`max_players`,
+  `bg`.`playing_time`,
+  `bg`.`min_age`,
+  CAST(`bg`.`is_expansion` AS UNSIGNED) AS `is_expansion`,
+  `bg`.`expansion_base_id`,
+  `bg`.`product_url`,
+  CAST(`bg`.`bodoge_hoobby_net` AS UNSIGNED) AS `bodoge_hoobby_net`,
+  CAST(`bg`.`score_tool` AS UNSIGNED) AS `score_tool`
+FROM `M_BOARDGAME` AS `bg`
+WHERE CAST(`bg`.`is_expansion` AS UNSIGNED) = 1
+  AND `bg`.`expansion_base_id` IN (
+  {{- range $i, $id := . }}{{ if $i }}, {{ end }}:base{{ $i }}{{ end -}}
+)
+ORDER BY `bg`.`expansion_base_id`, `bg`.`title`, `bg`.`id`;
\ No newline at end of file
@@ -0,0 +1,15 @@
+SELECT
+  `pp`.`play_id`,
+  `pp`.`player_id`,
+  `pp`.`name`,
+  `pp`.`avatar`,
+  `pp`.`color`,
+  `pp`.`points`,
+  `pp`.`total`,
+  `pp`.`rank`,
+  CAST(`pp`.`is_winner` AS UNSIGNED) AS `is_winner`
+FROM `T_PLAY_PLAYER` AS `pp`
+WHERE `pp`.`play_id` IN (
+  {{- range $i, $id := . }}{{ if $i }}, {{ end }}{{ $id }}{{ end -}}
+)
+ORDER BY `pp`.`play_id`, `pp`.`rank`, `pp`.`player_id`;
\ No newline at end of file
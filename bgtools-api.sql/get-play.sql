@@ -0,0 +1,9 @@
+SELECT
+  `play`.`id`,
+  `play`.`game_id`,
+  `play`.`room_id`,
+  `play`.`started_at`,
+  `play`.`played_at`
+FROM `T_PLAY` AS `play`
+WHERE `play`.`id` = :play_id
+  AND `play`.`is_hidden` = 0;
\ No newline at end of file
@@ -0,0 +1,13 @@
+SELECT
+  `play`.`id`,
+  `play`.`game_id`,
+  `play`.`room_id`,
+  `play`.`started_at`,
+  `play`.`played_at`
+FROM `T_PLAY` AS `play`
+WHERE `play`.`is_hidden` = 0
+{{- if .GameId }}
+  AND `play`.`game_id` = :game_id
+{{- end }}
+ORDER BY `play`.`played_at` DESC, `play`.`id` DESC
+LIMIT :limit OFFSET :offset;
\ No newline at end of file
@@ -0,0 +1,14 @@
+SELECT
+  `cat`.`game_id`,
+  `cat`.`seq`,
+  `cat`.`name`,
+  `cat`.`min_value`,
+  `cat`.`max_value`,
+  `cat`.`weight`,
+  CAST(`cat`.`is_total` AS UNSIGNED) AS `is_total`,
+  `cat`.`tie_break`
+FROM `M_SCORE_CATEGORY` AS `cat`
+INNER JOIN `M_BOARDGAME` AS `bg`
+  ON `bg`.`id` = `cat`.`game_id`
+WHERE CAST(`bg`.`score_tool` AS UNSIGNED) = 1
+ORDER BY `cat`.`game_id`, `cat`.`seq`;
\ No newline at end of file
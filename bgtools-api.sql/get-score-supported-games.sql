@@ -3,8 +3,11 @@ SELECT
   `bg`.`title`,
   `bg`.`min_players`,
   `bg`.`max_players`,
-  `col`.`color`
+  CAST(`bg`.`is_expansion` AS UNSIGNED) AS `is_expansion`,
+  `bg`.`expansion_base_id`,
+  COALESCE(`col`.`color`, '') AS `color`
 FROM `M_BOARDGAME` AS `bg`
-INNER JOIN `M_COLOR` AS `col`
+LEFT JOIN `M_COLOR` AS `col`
   ON `bg`.`id` = `col`.`game_id`
-WHERE CAST(`bg`.`score_tool` AS UNSIGNED) = 1;
\ No newline at end of file
+WHERE CAST(`bg`.`score_tool` AS UNSIGNED) = 1
+  AND (`col`.`color` IS NOT NULL OR CAST(`bg`.`is_expansion` AS UNSIGNED) = 1);
\ No newline at end of file
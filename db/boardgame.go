@@ -0,0 +1,237 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+
+	"bgtools-api/models"
+)
+
+// <summary>: ボードゲームが存在しない時のエラー
+var ErrNoBoardgame = errors.New("ボードゲームが存在しません")
+
+// <summary>: ボードゲームの情報を登録済みの色と併せて読み込みます
+// <remark>: 存在しなければErrNoBoardgameを返す
+func LoadBoardgame(r *BgRepository, id string) (models.BoardgameDetail, error) {
+	if r == nil {
+		e := errors.New("DBの接続に失敗しました")
+		return models.BoardgameDetail{}, e
+	}
+
+	bg, err := r.GetBoardgame(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.BoardgameDetail{}, ErrNoBoardgame
+
+	} else if err != nil {
+		return models.BoardgameDetail{}, err
+	}
+
+	cols, err := r.GetColors(id)
+	if err != nil {
+		return models.BoardgameDetail{}, err
+	}
+
+	detail := models.BoardgameDetail{
+		MstrBoardgame: bg,
+		Colors:        make([]string, 0, len(cols)),
+	}
+
+	for _, c := range cols {
+		detail.Colors = append(detail.Colors, c.Color)
+	}
+
+	return detail, nil
+}
+
+// <summary>: 条件に合うボードゲームの一覧を読み込みます
+func LoadBoardgames(r *BgRepository, q models.BoardgameQuery) (models.BoardgameList, error) {
+	if r == nil {
+		e := errors.New("DBの接続に失敗しました")
+		return models.BoardgameList{}, e
+	}
+
+	total, err := r.CountBoardgames(q)
+	if err != nil {
+		return models.BoardgameList{}, err
+	}
+
+	list, err := r.GetBoardgames(q)
+	if err != nil {
+		return models.BoardgameList{}, err
+	}
+
+	nodes := make([]models.BoardgameNode, 0, len(list))
+	ids := make([]string, 0, len(list))
+
+	for _, bg := range list {
+		nodes = append(nodes, models.BoardgameNode{MstrBoardgame: bg})
+		ids = append(ids, bg.Id)
+	}
+
+	// 入れ子にする指定があれば、一覧に含まれるボードゲームの拡張を読み込む
+	if q.Nest {
+		exps, err := r.GetExpansions(ids)
+		if err != nil {
+			return models.BoardgameList{}, err
+		}
+
+		children := make(map[string][]models.MstrBoardgame, len(ids))
+
+		for _, e := range exps {
+			children[e.ExpansionBaseId] = append(children[e.ExpansionBaseId], e)
+		}
+
+		for i := range nodes {
+			nodes[i].Expansions = children[nodes[i].Id]
+
+			if nodes[i].Expansions == nil {
+				nodes[i].Expansions = []models.MstrBoardgame{}
+			}
+		}
+	}
+
+	rv := models.BoardgameList{
+		Total:      total,
+		Limit:      q.Limit,
+		Offset:     q.Offset,
+		Boardgames: nodes,
+	}
+
+	return rv, nil
+}
+
+// <summary>: ボードゲームの情報を、拡張を入れ子にして読み込みます
+// <remark>: 拡張も登録済みの色と併せて読み込み、存在しなければErrNoBoardgameを返す
+func LoadBoardgameTree(r *BgRepository, id string) (models.BoardgameTree, error) {
+	detail, err := LoadBoardgame(r, id)
+	if err != nil {
+		return models.BoardgameTree{}, err
+	}
+
+	exps, err := r.GetExpansions([]string{id})
+	if err != nil {
+		return models.BoardgameTree{}, err
+	}
+
+	tree := models.BoardgameTree{
+		BoardgameDetail: detail,
+		Expansions:      make([]models.BoardgameDetail, 0, len(exps)),
+	}
+
+	for _, e := range exps {
+		cols, err := r.GetColors(e.Id)
+		if err != nil {
+			return models.BoardgameTree{}, err
+		}
+
+		child := models.BoardgameDetail{
+			MstrBoardgame: e,
+			Colors:        make([]string, 0, len(cols)),
+		}
+
+		for _, c := range cols {
+			child.Colors = append(child.Colors, c.Color)
+		}
+
+		tree.Expansions = append(tree.Expansions, child)
+	}
+
+	return tree, nil
+}
+
+// <summary>: ボードゲームに拡張が登録されているか確認します
+func HasExpansions(r *BgRepository, id string) (bool, error) {
+	if r == nil {
+		e := errors.New("DBの接続に失敗しました")
+		return false, e
+	}
+
+	exps, err := r.GetExpansions([]string{id})
+	if err != nil {
+		return false, err
+	}
+
+	return len(exps) > 0, nil
+}
+
+// <summary>: ボードゲームの情報を色と併せて登録します
+func CreateBoardgame(r *BgRepository, d models.BoardgameDetail) error {
+	if r == nil {
+		e := errors.New("DBの接続に失敗しました")
+		return e
+	}
+
+	bg := d.MstrBoardgame
+	return r.InsertBoardgame(&bg, toColors(d.Id, d.Colors))
+}
+
+// <summary>: ボードゲームの情報を更新します
+// <remark>: 色が指定されていれば、登録済みの色を全て置き換える
+func ModifyBoardgame(r *BgRepository, d models.BoardgameDetail) error {
+	if r == nil {
+		e := errors.New("DBの接続に失敗しました")
+		return e
+	}
+
+	var cols []models.MstrColor
+	if d.Colors != nil {
+		cols = toColors(d.Id, d.Colors)
+	}
+
+	bg := d.MstrBoardgame
+	return r.UpdateBoardgame(&bg, cols)
+}
+
+// <summary>: ボードゲームの情報を、色と得点表の定義と併せて削除します
+func RemoveBoardgame(r *BgRepository, id string) error {
+	if r == nil {
+		e := errors.New("DBの接続に失敗しました")
+		return e
+	}
+
+	return r.DeleteBoardgame(id)
+}
+
+// <summary>: ボードゲームに色を追加します
+func AddColor(r *BgRepository, id, color string) error {
+	if r == nil {
+		e := errors.New("DBの接続に失敗しました")
+		return e
+	}
+
+	col := models.MstrColor{
+		GameId: id,
+		Color:  color,
+	}
+
+	return r.InsertColor(&col)
+}
+
+// <summary>: ボードゲームから色を削除します
+func RemoveColor(r *BgRepository, id, color string) error {
+	if r == nil {
+		e := errors.New("DBの接続に失敗しました")
+		return e
+	}
+
+	col := models.MstrColor{
+		GameId: id,
+		Color:  color,
+	}
+
+	return r.DeleteColor(col)
+}
+
+// <summary>: 色の一覧をM_COLORの行に変換します
+func toColors(id string, colors []string) []models.MstrColor {
+	cols := make([]models.MstrColor, 0, len(colors))
+
+	for _, c := range colors {
+		cols = append(cols, models.MstrColor{
+			GameId: id,
+			Color:  c,
+		})
+	}
+
+	return cols
+}
@@ -2,12 +2,29 @@ package db
 
 import (
 	"errors"
+	"sync"
 
 	"bgtools-api/models"
+	"bgtools-api/score"
+)
+
+var (
+	// <summary>: ボードゲームのデータの読み込みを直列にするためのロック
+	loadMu sync.Mutex
 )
 
 // <summary>: ボードゲームのデータを読み込みます
+// <remark>: 読み込んだデータで丸ごと差し替えるため、何度呼び出しても結果は同じになる
 func LoadBgDataForScore(r *BgRepository) error {
+	loadMu.Lock()
+	defer loadMu.Unlock()
+
+	return loadBgDataForScore(r)
+}
+
+// <summary>: ボードゲームのデータを読み込みます
+// <remark>: 呼び出し元でloadMuのロックを取得しておく
+func loadBgDataForScore(r *BgRepository) error {
 	if r == nil {
 		e := errors.New("DBの接続に失敗しました")
 		return e
@@ -18,27 +35,70 @@ func LoadBgDataForScore(r *BgRepository) error {
 		return err
 	}
 
+	catalog := make(map[string]models.BgPartialData)
+
 	for _, data := range list {
-		_, ok := models.BgScore[data.GameId]
+		_, ok := catalog[data.GameId]
 		bgd := models.BgPartialData{}
 
 		if !ok {
 			bgd.Title = data.Title
 			bgd.MinPlayers = data.MinPlayers
 			bgd.MaxPlayers = data.MaxPlayers
+			bgd.IsExpansion = data.IsExpansion
+			bgd.BaseId = data.ExpansionBaseId
 
 			bgd.Colors = make([]string, 0, data.MaxPlayers)
-			bgd.Colors = append(bgd.Colors, data.Color)
+			bgd.Categories = []models.ScoreCategory{}
 
-			models.BgScore[data.GameId] = bgd
+			catalog[data.GameId] = bgd
+		}
 
-		} else {
-			d := models.BgScore[data.GameId]
-			d.Colors = append(d.Colors, data.Color)
+		// 色を持たない拡張は、基本のボードゲームの色のみを使用する
+		if data.Color == "" {
+			continue
+		}
+
+		d := catalog[data.GameId]
+		d.Colors = append(d.Colors, data.Color)
+
+		catalog[data.GameId] = d
+	}
 
-			models.BgScore[data.GameId] = d
+	cats, err := r.GetScoreCategories()
+	if err != nil {
+		return err
+	}
+
+	for _, cat := range cats {
+		d, ok := catalog[cat.GameId]
+		if !ok {
+			continue
 		}
+
+		d.Categories = append(d.Categories, models.ScoreCategory{
+			Name:     cat.Name,
+			Min:      cat.MinValue,
+			Max:      cat.MaxValue,
+			Weight:   cat.Weight,
+			IsTotal:  cat.IsTotal,
+			TieBreak: cat.TieBreak,
+		})
+
+		catalog[cat.GameId] = d
 	}
 
+	// 得点表で同点時の比較が定義されていれば、その得点計算を登録する
+	for id, d := range catalog {
+		if tb := score.TieBreakers(d.Categories); len(tb) > 0 {
+			score.Register(id, score.Standard{TieBreakers: tb})
+
+		} else {
+			score.Unregister(id)
+		}
+	}
+
+	models.StoreBgScore(catalog)
+
 	return nil
 }
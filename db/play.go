@@ -0,0 +1,149 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+
+	"bgtools-api/models"
+)
+
+// <summary>: プレイ記録が存在しない時のエラー
+var ErrNoPlay = errors.New("プレイ記録が存在しません")
+
+// <summary>: プレイ記録を保存します
+// <remark>: 保存に成功すれば、採番されたプレイIDを設定して返す
+// <remark>: ctxの期限を過ぎれば、保存を中断してエラーを返す
+func SavePlay(ctx context.Context, r *BgRepository, rec models.PlayRecord) (models.PlayRecord, error) {
+	if r == nil {
+		e := errors.New("DBの接続に失敗しました")
+		return rec, e
+	}
+
+	play := models.TranPlay{
+		GameId:    rec.GameId,
+		RoomId:    rec.RoomId,
+		StartedAt: rec.StartedAt,
+		PlayedAt:  rec.PlayedAt,
+		IsHidden:  rec.IsHidden,
+	}
+
+	players := make([]models.TranPlayPlayer, 0, len(rec.Players))
+
+	for _, p := range rec.Players {
+		points, err := json.Marshal(p.Points)
+		if err != nil {
+			return rec, err
+		}
+
+		players = append(players, models.TranPlayPlayer{
+			PlayerId: p.PlayerId,
+			Name:     p.Name,
+			Avatar:   p.Avatar,
+			Color:    p.Color,
+			Points:   string(points),
+			Total:    p.Total,
+			Rank:     p.Rank,
+			IsWinner: p.IsWinner,
+		})
+	}
+
+	if err := r.InsertPlay(ctx, &play, players); err != nil {
+		return rec, err
+	}
+
+	rec.PlayId = play.Id
+	return rec, nil
+}
+
+// <summary>: 条件に合うプレイ記録の一覧を読み込みます
+// <remark>: 非公開、もしくは合言葉が必要な部屋のプレイ記録は含めない
+func LoadPlays(r *BgRepository, q models.PlayQuery) ([]models.PlayRecord, error) {
+	if r == nil {
+		e := errors.New("DBの接続に失敗しました")
+		return nil, e
+	}
+
+	plays, err := r.GetPlays(q)
+	if err != nil {
+		return nil, err
+	}
+
+	return attachPlayers(r, plays)
+}
+
+// <summary>: 指定されたプレイ記録を読み込みます
+// <remark>: 存在しない、もしくは非公開、合言葉が必要な部屋のプレイ記録であればErrNoPlayを返す
+func LoadPlay(r *BgRepository, id int64) (models.PlayRecord, error) {
+	if r == nil {
+		e := errors.New("DBの接続に失敗しました")
+		return models.PlayRecord{}, e
+	}
+
+	play, err := r.GetPlay(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.PlayRecord{}, ErrNoPlay
+
+	} else if err != nil {
+		return models.PlayRecord{}, err
+	}
+
+	recs, err := attachPlayers(r, []models.TranPlay{play})
+	if err != nil {
+		return models.PlayRecord{}, err
+	}
+
+	return recs[0], nil
+}
+
+// <summary>: プレイ記録にプレイヤー毎の結果を紐付けます
+func attachPlayers(r *BgRepository, plays []models.TranPlay) ([]models.PlayRecord, error) {
+	ids := make([]int64, 0, len(plays))
+	index := make(map[int64]int, len(plays))
+	recs := make([]models.PlayRecord, 0, len(plays))
+
+	for i, p := range plays {
+		ids = append(ids, p.Id)
+		index[p.Id] = i
+
+		recs = append(recs, models.PlayRecord{
+			PlayId:    p.Id,
+			GameId:    p.GameId,
+			RoomId:    p.RoomId,
+			StartedAt: p.StartedAt,
+			PlayedAt:  p.PlayedAt,
+			Players:   []models.PlayerRecord{},
+		})
+	}
+
+	players, err := r.GetPlayPlayers(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, p := range players {
+		i, ok := index[p.PlayId]
+		if !ok {
+			continue
+		}
+
+		points := []int{}
+		if err := json.Unmarshal([]byte(p.Points), &points); err != nil {
+			return nil, err
+		}
+
+		recs[i].Players = append(recs[i].Players, models.PlayerRecord{
+			PlayerId: p.PlayerId,
+			Name:     p.Name,
+			Avatar:   p.Avatar,
+			Color:    p.Color,
+			Points:   points,
+			Total:    p.Total,
+			Rank:     p.Rank,
+			IsWinner: p.IsWinner,
+		})
+	}
+
+	return recs, nil
+}
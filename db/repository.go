@@ -1,6 +1,9 @@
 package db
 
 import (
+	"context"
+	"fmt"
+
 	"bgtools-api/models"
 
 	"github.com/go-gorp/gorp"
@@ -24,3 +27,283 @@ func (r *BgRepository) GetScoreSupported() ([]models.BgScoreSupport, error) {
 
 	return result, nil
 }
+
+func (r *BgRepository) GetScoreCategories() ([]models.MstrScoreCategory, error) {
+	var result []models.MstrScoreCategory
+	query := GetSQL("get-score-categories", "")
+
+	if _, err := r.Select(&result, query); err != nil {
+		return []models.MstrScoreCategory{}, err
+	}
+
+	return result, nil
+}
+
+func (r *BgRepository) InsertPlay(ctx context.Context, play *models.TranPlay, players []models.TranPlayPlayer) error {
+	// トランザクションの開始から期限付きで行う
+	dbmap := r.DbMap
+	if m, ok := r.WithContext(ctx).(*gorp.DbMap); ok {
+		dbmap = m
+	}
+
+	tx, err := dbmap.Begin()
+	if err != nil {
+		return err
+	}
+
+	exec := tx.WithContext(ctx)
+
+	if err := exec.Insert(play); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	for i := range players {
+		players[i].PlayId = play.Id
+
+		if err := exec.Insert(&players[i]); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *BgRepository) GetPlays(q models.PlayQuery) ([]models.TranPlay, error) {
+	var result []models.TranPlay
+	query := GetSQL("get-plays", q)
+
+	params := map[string]interface{}{
+		"game_id": q.GameId,
+		"limit":   q.Limit,
+		"offset":  q.Offset,
+	}
+
+	if _, err := r.Select(&result, query, params); err != nil {
+		return []models.TranPlay{}, err
+	}
+
+	return result, nil
+}
+
+func (r *BgRepository) GetPlay(id int64) (models.TranPlay, error) {
+	var result models.TranPlay
+	query := GetSQL("get-play", "")
+
+	params := map[string]interface{}{
+		"play_id": id,
+	}
+
+	if err := r.SelectOne(&result, query, params); err != nil {
+		return models.TranPlay{}, err
+	}
+
+	return result, nil
+}
+
+func (r *BgRepository) GetPlayPlayers(ids []int64) ([]models.TranPlayPlayer, error) {
+	var result []models.TranPlayPlayer
+
+	if len(ids) == 0 {
+		return []models.TranPlayPlayer{}, nil
+	}
+
+	query := GetSQL("get-play-players", ids)
+
+	if _, err := r.Select(&result, query); err != nil {
+		return []models.TranPlayPlayer{}, err
+	}
+
+	return result, nil
+}
+
+func (r *BgRepository) GetBoardgame(id string) (models.MstrBoardgame, error) {
+	var result models.MstrBoardgame
+	query := GetSQL("get-boardgame", "")
+
+	params := map[string]interface{}{
+		"game_id": id,
+	}
+
+	if err := r.SelectOne(&result, query, params); err != nil {
+		return models.MstrBoardgame{}, err
+	}
+
+	return result, nil
+}
+
+func (r *BgRepository) GetColors(id string) ([]models.MstrColor, error) {
+	var result []models.MstrColor
+	query := GetSQL("get-colors", "")
+
+	params := map[string]interface{}{
+		"game_id": id,
+	}
+
+	if _, err := r.Select(&result, query, params); err != nil {
+		return []models.MstrColor{}, err
+	}
+
+	return result, nil
+}
+
+func (r *BgRepository) InsertBoardgame(bg *models.MstrBoardgame, colors []models.MstrColor) error {
+	tx, err := r.Begin()
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Insert(bg); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	for i := range colors {
+		if err := tx.Insert(&colors[i]); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *BgRepository) UpdateBoardgame(bg *models.MstrBoardgame, colors []models.MstrColor) error {
+	tx, err := r.Begin()
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Update(bg); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	// 色が指定されていなければ、登録済みの色を変更しない
+	if colors != nil {
+		query := GetSQL("delete-colors", models.MstrColor{})
+
+		params := map[string]interface{}{
+			"game_id": bg.Id,
+		}
+
+		if _, err := tx.Exec(query, params); err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		for i := range colors {
+			if err := tx.Insert(&colors[i]); err != nil {
+				tx.Rollback()
+				return err
+			}
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *BgRepository) DeleteBoardgame(id string) error {
+	tx, err := r.Begin()
+	if err != nil {
+		return err
+	}
+
+	params := map[string]interface{}{
+		"game_id": id,
+	}
+
+	queries := []string{
+		GetSQL("delete-colors", models.MstrColor{}),
+		GetSQL("delete-score-categories", ""),
+	}
+
+	for _, query := range queries {
+		if _, err := tx.Exec(query, params); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	if _, err := tx.Delete(&models.MstrBoardgame{Id: id}); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *BgRepository) InsertColor(col *models.MstrColor) error {
+	return r.Insert(col)
+}
+
+func (r *BgRepository) DeleteColor(col models.MstrColor) error {
+	query := GetSQL("delete-colors", col)
+
+	params := map[string]interface{}{
+		"game_id": col.GameId,
+		"color":   col.Color,
+	}
+
+	_, err := r.Exec(query, params)
+	return err
+}
+
+func (r *BgRepository) GetBoardgames(q models.BoardgameQuery) ([]models.MstrBoardgame, error) {
+	var result []models.MstrBoardgame
+
+	q.Count = false
+	query := GetSQL("get-boardgames", q)
+
+	if _, err := r.Select(&result, query, boardgameParams(q)); err != nil {
+		return []models.MstrBoardgame{}, err
+	}
+
+	return result, nil
+}
+
+func (r *BgRepository) GetExpansions(baseIds []string) ([]models.MstrBoardgame, error) {
+	var result []models.MstrBoardgame
+
+	if len(baseIds) == 0 {
+		return []models.MstrBoardgame{}, nil
+	}
+
+	query := GetSQL("get-expansions", baseIds)
+	params := make(map[string]interface{}, len(baseIds))
+
+	for i, id := range baseIds {
+		params[fmt.Sprintf("base%d", i)] = id
+	}
+
+	if _, err := r.Select(&result, query, params); err != nil {
+		return []models.MstrBoardgame{}, err
+	}
+
+	return result, nil
+}
+
+func (r *BgRepository) CountBoardgames(q models.BoardgameQuery) (int64, error) {
+	q.Count = true
+	query := GetSQL("get-boardgames", q)
+
+	return r.SelectInt(query, boardgameParams(q))
+}
+
+func boardgameParams(q models.BoardgameQuery) map[string]interface{} {
+	params := map[string]interface{}{
+		"players":  q.Players,
+		"age":      q.Age,
+		"max_time": q.MaxTime,
+		"base_id":  q.BaseId,
+		"limit":    q.Limit,
+		"offset":   q.Offset,
+	}
+
+	if q.IsExpansion != nil {
+		params["is_expansion"] = *q.IsExpansion
+	}
+
+	return params
+}
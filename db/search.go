@@ -0,0 +1,52 @@
+package db
+
+import (
+	"errors"
+
+	"bgtools-api/models"
+	"bgtools-api/search"
+)
+
+// <summary>: ボードゲームの検索用索引をDBから構築し直します
+// <remark>: 構築した索引で丸ごと差し替えるため、検索中の処理には影響しない
+func LoadSearchIndex(r *BgRepository) error {
+	loadMu.Lock()
+	defer loadMu.Unlock()
+
+	return loadSearchIndex(r)
+}
+
+// <summary>: ボードゲームの検索用索引をDBから構築し直します
+// <remark>: 呼び出し元でloadMuのロックを取得しておく
+func loadSearchIndex(r *BgRepository) error {
+	if r == nil {
+		e := errors.New("DBの接続に失敗しました")
+		return e
+	}
+
+	q := models.BoardgameQuery{
+		Sort: "`bg`.`id`",
+	}
+
+	list, err := r.GetBoardgames(q)
+	if err != nil {
+		return err
+	}
+
+	search.Store(search.NewIndex(list))
+
+	return nil
+}
+
+// <summary>: スコア用のボードゲーム情報と検索用索引を併せて読み込み直します
+// <remark>: 同時に読み込み直しても両者が食い違わないよう、読み込みの間はロックを保持する
+func ReloadCatalog(r *BgRepository) error {
+	loadMu.Lock()
+	defer loadMu.Unlock()
+
+	if err := loadBgDataForScore(r); err != nil {
+		return err
+	}
+
+	return loadSearchIndex(r)
+}
@@ -16,8 +16,19 @@ import (
 )
 
 type connectConfig struct {
-	Type string         `toml:"db_type"`
-	DB   databaseConfig `toml:"database"`
+	Type   string         `toml:"db_type"`
+	DB     databaseConfig `toml:"database"`
+	Admin  adminConfig    `toml:"admin"`
+	Server ServerConfig   `toml:"server"`
+}
+
+type adminConfig struct {
+	Token string `toml:"token"`
+}
+
+// <summary>: サーバの動作に関する設定
+type ServerConfig struct {
+	TrustedProxies []string `toml:"trusted_proxies"`
 }
 
 type databaseConfig struct {
@@ -44,16 +55,8 @@ var (
 
 
 func GetDataSourceName() (string, string, error) {
-	dir := getDirName()
-	if dir == "" {
-		e := errors.New("実行ファイル名の取得に失敗しました")
-		return "", "", e
-	}
-
-	f := filepath.Join(dir, "connect.toml")
-	var conf connectConfig
-
-	if _, err := toml.DecodeFile(f, &conf); err != nil {
+	conf, err := readConfig()
+	if err != nil {
 		return "", "", err
 	}
 
@@ -61,7 +64,7 @@ func GetDataSourceName() (string, string, error) {
 
 	switch conf.Type {
 	case "mysql":
-		dsn = fmt.Sprintf("%s:%s@tcp(%s:%d)/%s",
+		dsn = fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?parseTime=true",
 			conf.DB.User, conf.DB.Password,
 			conf.DB.Server, conf.DB.Port,
 			conf.DB.DBName,
@@ -72,13 +75,31 @@ func GetDataSourceName() (string, string, error) {
 				return "", "", err
 			}
 
-			dsn += "?tls=custom"
+			dsn += "&tls=custom"
 		}
 	}
 
 	return conf.Type, dsn, nil
 }
 
+func GetAdminToken() (string, error) {
+	conf, err := readConfig()
+	if err != nil {
+		return "", err
+	}
+
+	return conf.Admin.Token, nil
+}
+
+func GetServerConfig() (ServerConfig, error) {
+	conf, err := readConfig()
+	if err != nil {
+		return ServerConfig{}, err
+	}
+
+	return conf.Server, nil
+}
+
 func GetSQL(name string, req interface{}) string {
 	dir := getDirName()
 	if dir == "" {
@@ -94,6 +115,23 @@ func GetSQL(name string, req interface{}) string {
 	return buf.String()
 }
 
+func readConfig() (connectConfig, error) {
+	dir := getDirName()
+	if dir == "" {
+		e := errors.New("実行ファイル名の取得に失敗しました")
+		return connectConfig{}, e
+	}
+
+	f := filepath.Join(dir, "connect.toml")
+	var conf connectConfig
+
+	if _, err := toml.DecodeFile(f, &conf); err != nil {
+		return connectConfig{}, err
+	}
+
+	return conf, nil
+}
+
 func getDirName() string {
 	exe, err := os.Executable()
 	if err != nil {
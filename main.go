@@ -1,18 +1,36 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
+	"bgtools-api/db"
 	"bgtools-api/web"
 	"bgtools-api/ws"
 )
 
 const LISTEN_PORT string = ":8506"
 
+// 停止処理に許容する時間
+const SHUTDOWN_TIMEOUT time.Duration = 10 * time.Second
+
 var (
 	Version string
 	Revision string
+
+	snapshotFile = flag.String("snapshot", "bgtools-api.snapshot.json", "終了時に部屋情報を退避するファイル")
+
+	defaultTimeouts   = ws.DefaultTimeouts()
+	idleTimeout       = flag.Duration("idle-timeout", defaultTimeouts.Idle, "応答のない接続を切断するまでの時間")
+	roomIdleTimeout   = flag.Duration("room-idle-timeout", defaultTimeouts.RoomIdle, "操作のない部屋を閉じるまでの時間")
+	resumeGrace       = flag.Duration("resume-grace", defaultTimeouts.ResumeGrace, "切断後に席を保持しておく猶予期間")
+	roomCodeRetention = flag.Duration("room-code-retention", defaultTimeouts.RoomCodeRetention, "部屋が閉じられた後、部屋IDを再利用しない期間")
 )
 
 // <summary>: main関数（サーバを開始します）
@@ -24,7 +42,88 @@ func main() {
 		return
 	}
 
+	// 部屋の復元や接続の受け付けより前に、時間に関する設定を反映する
+	ws.Configure(ws.Timeouts{
+		Idle:              *idleTimeout,
+		RoomIdle:          *roomIdleTimeout,
+		ResumeGrace:       *resumeGrace,
+		RoomCodeRetention: *roomCodeRetention,
+	})
+
+	router := web.SetupRouter()
+
+	// 前回の終了時に退避した部屋があれば復元する
+	if _, err := ws.RestoreSnapshot(*snapshotFile); err != nil {
+		fmt.Printf("RestoreSnapshot: %v\n", err)
+	}
+
 	go ws.ServeRequest()
+	go waitForReload()
+
+	srv := &http.Server{
+		Addr:    LISTEN_PORT,
+		Handler: router,
+	}
+
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			fmt.Printf("ListenAndServe: %v\n", err)
+			os.Exit(1)
+		}
+	}()
+
+	os.Exit(waitForShutdown(srv))
+}
+
+// <summary>: SIGHUPを受信する度に、ボードゲーム情報をDBから読み込み直します
+func waitForReload() {
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGHUP)
+
+	for range sig {
+		if err := db.ReloadCatalog(db.BgRepo); err != nil {
+			fmt.Printf("LoadBgData: %v\n", err)
+			continue
+		}
+
+		fmt.Println("LoadBgData: ボードゲーム情報を読み込み直しました")
+	}
+}
+
+// <summary>: 終了シグナルを受信すれば、接続を切断し部屋情報を退避します
+// <remark>: 新たな接続の受付を止めてから既存の接続を切断し、それぞれ期限内で打ち切る
+// <remark>: 部屋情報の退避は期限を過ぎても書き込みきる
+func waitForShutdown(srv *http.Server) int {
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGTERM, syscall.SIGINT)
+
+	<-sig
+
+	rv := 0
+
+	// 新たな接続の受付を止め、処理中のリクエストの完了を待つ
+	hctx, hcancel := context.WithTimeout(context.Background(), SHUTDOWN_TIMEOUT)
+	defer hcancel()
+
+	if err := srv.Shutdown(hctx); err != nil {
+		fmt.Printf("Shutdown: %v\n", err)
+		rv = 1
+	}
+
+	// 全ての接続へ停止を通知する
+	wctx, wcancel := context.WithTimeout(context.Background(), SHUTDOWN_TIMEOUT)
+	defer wcancel()
+
+	if err := ws.Shutdown(wctx); err != nil {
+		fmt.Printf("Shutdown: 期限内に停止処理が終わりませんでした: %v\n", err)
+		rv = 1
+	}
+
+	// 停止処理が期限を過ぎていても、その時点の部屋情報を退避する
+	if err := ws.SaveSnapshot(*snapshotFile); err != nil {
+		fmt.Printf("SaveSnapshot: %v\n", err)
+		rv = 1
+	}
 
-	web.SetupRouter().Run(LISTEN_PORT)
+	return rv
 }
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS `M_SCORE_CATEGORY`;
\ No newline at end of file
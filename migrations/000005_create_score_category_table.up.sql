@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS `M_SCORE_CATEGORY` (
+  `game_id` VARCHAR(8) NOT NULL DEFAULT '',
+  `seq` TINYINT UNSIGNED NOT NULL DEFAULT '0',
+  `name` VARCHAR(256) NOT NULL DEFAULT '',
+  `min_value` INT NOT NULL DEFAULT '0',
+  `max_value` INT NOT NULL DEFAULT '0',
+  `weight` INT NOT NULL DEFAULT '1',
+  `is_total` BIT(1) NOT NULL DEFAULT b'1',
+  UNIQUE id_seq (`game_id`, `seq`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS `T_PLAY`;
\ No newline at end of file
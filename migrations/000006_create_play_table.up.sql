@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS `T_PLAY` (
+  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT PRIMARY KEY,
+  `game_id` VARCHAR(8) NOT NULL DEFAULT '',
+  `room_id` VARCHAR(16) NOT NULL DEFAULT '',
+  `started_at` DATETIME NOT NULL,
+  `played_at` DATETIME NOT NULL,
+  `is_hidden` BIT(1) NOT NULL DEFAULT b'0',
+  INDEX game_played (`game_id`, `played_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
\ No newline at end of file
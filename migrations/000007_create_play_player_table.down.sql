@@ -0,0 +1 @@
+DROP TABLE IF EXISTS `T_PLAY_PLAYER`;
\ No newline at end of file
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS `T_PLAY_PLAYER` (
+  `play_id` BIGINT UNSIGNED NOT NULL DEFAULT '0',
+  `player_id` VARCHAR(16) NOT NULL DEFAULT '',
+  `name` VARCHAR(64) NOT NULL DEFAULT '',
+  `avatar` VARCHAR(32) NOT NULL DEFAULT '',
+  `color` VARCHAR(16) NOT NULL DEFAULT '',
+  `points` VARCHAR(1024) NOT NULL DEFAULT '[]',
+  `total` INT NOT NULL DEFAULT '0',
+  `rank` TINYINT UNSIGNED NOT NULL DEFAULT '0',
+  `is_winner` BIT(1) NOT NULL DEFAULT b'0',
+  UNIQUE id_player (`play_id`, `player_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
\ No newline at end of file
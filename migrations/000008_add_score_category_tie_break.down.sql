@@ -0,0 +1,2 @@
+ALTER TABLE `M_SCORE_CATEGORY`
+  DROP COLUMN `tie_break`;
\ No newline at end of file
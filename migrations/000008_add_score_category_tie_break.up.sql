@@ -0,0 +1,2 @@
+ALTER TABLE `M_SCORE_CATEGORY`
+  ADD COLUMN `tie_break` TINYINT UNSIGNED NOT NULL DEFAULT '0' AFTER `is_total`;
\ No newline at end of file
@@ -1,9 +1,30 @@
 package models
 
-import "github.com/go-gorp/gorp"
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/go-gorp/gorp"
+)
 
 // <summary>: 対応しているボードゲームの情報
-var BgScore = map[string]BgPartialData{}
+// <remark>: 読み込み直す際は丸ごと差し替え、格納済みのmapは変更しない
+var bgScore atomic.Value
+
+func init() {
+	bgScore.Store(map[string]BgPartialData{})
+}
+
+// <summary>: 対応しているボードゲームの情報を取得します
+// <remark>: 返されたmapは読み取り専用として扱う
+func BgScore() map[string]BgPartialData {
+	return bgScore.Load().(map[string]BgPartialData)
+}
+
+// <summary>: 対応しているボードゲームの情報を差し替えます
+func StoreBgScore(m map[string]BgPartialData) {
+	bgScore.Store(m)
+}
 
 type MstrBoardgame struct {
 	Id              string `db:"id, primarykey" json:"id"`
@@ -32,17 +53,51 @@ type MstrColor struct {
 	Color  string `db:"color" json:"color"`
 }
 
+type MstrScoreCategory struct {
+	GameId   string `db:"game_id" json:"game_id"`
+	Seq      int    `db:"seq" json:"seq"`
+	Name     string `db:"name" json:"name"`
+	MinValue int    `db:"min_value" json:"min_value"`
+	MaxValue int    `db:"max_value" json:"max_value"`
+	Weight   int    `db:"weight" json:"weight"`
+	IsTotal  bool   `db:"is_total" json:"is_total"`
+	TieBreak int    `db:"tie_break" json:"tie_break"`
+}
+
 type TranOwn struct {
 	UserId string `db:"user_id" json:"user_id"`
 	GameId string `db:"game_id" json:"game_id"`
 }
 
+type TranPlay struct {
+	Id        int64     `db:"id, primarykey, autoincrement" json:"id"`
+	GameId    string    `db:"game_id" json:"game_id"`
+	RoomId    string    `db:"room_id" json:"room_id"`
+	StartedAt time.Time `db:"started_at" json:"started_at"`
+	PlayedAt  time.Time `db:"played_at" json:"played_at"`
+	IsHidden  bool      `db:"is_hidden" json:"-"`
+}
+
+type TranPlayPlayer struct {
+	PlayId   int64  `db:"play_id" json:"play_id"`
+	PlayerId string `db:"player_id" json:"player_id"`
+	Name     string `db:"name" json:"name"`
+	Avatar   string `db:"avatar" json:"avatar"`
+	Color    string `db:"color" json:"color"`
+	Points   string `db:"points" json:"points"`
+	Total    int    `db:"total" json:"total"`
+	Rank     int    `db:"rank" json:"rank"`
+	IsWinner bool   `db:"is_winner" json:"is_winner"`
+}
+
 type BgScoreSupport struct {
-	GameId     string `db:"game_id"`
-	Title      string `db:"title"`
-	MinPlayers int    `db:"min_players"`
-	MaxPlayers int    `db:"max_players"`
-	Color      string `db:"color"`
+	GameId          string `db:"game_id"`
+	Title           string `db:"title"`
+	MinPlayers      int    `db:"min_players"`
+	MaxPlayers      int    `db:"max_players"`
+	IsExpansion     bool   `db:"is_expansion"`
+	ExpansionBaseId string `db:"expansion_base_id"`
+	Color           string `db:"color"`
 }
 
 // MapStructsToTables 構造体と物理テーブルの紐付け
@@ -50,5 +105,8 @@ func MapStructsToTables(dbmap *gorp.DbMap) {
 	dbmap.AddTableWithName(MstrBoardgame{}, "M_BOARDGAME").SetKeys(false, "Id")
 	dbmap.AddTableWithName(MstrUser{}, "M_USER").SetKeys(false, "Id")
 	dbmap.AddTableWithName(MstrColor{}, "M_COLOR")
+	dbmap.AddTableWithName(MstrScoreCategory{}, "M_SCORE_CATEGORY")
 	dbmap.AddTableWithName(TranOwn{}, "T_OWN")
+	dbmap.AddTableWithName(TranPlay{}, "T_PLAY").SetKeys(true, "Id")
+	dbmap.AddTableWithName(TranPlayPlayer{}, "T_PLAY_PLAYER")
 }
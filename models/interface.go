@@ -1,25 +1,109 @@
 package models
 
+import (
+	"fmt"
+	"time"
+)
+
 // <summary>: プレーヤーの情報
+// <remark>: ConnIdは本人以外に公開しないため、JSONには含めない
 type PlayerInfoSet struct {
-	ConnId      string `json:"connection_id"`
+	ConnId      string `json:"-"`
+	PlayerId    string `json:"player_id"`
+	Name        string `json:"name"`
+	Avatar      string `json:"avatar"`
 	PlayerColor string `json:"player_color"`
+	IsConnected bool   `json:"is_connected"`
+	Points      []int  `json:"points"`
+	Total       int    `json:"total"`
+}
+
+// <summary>: 観戦者の情報
+// <remark>: ConnIdは本人以外に公開しないため、JSONには含めない
+type SpectatorInfoSet struct {
+	ConnId   string `json:"-"`
+	PlayerId string `json:"player_id"`
 }
 
 // <summary>: 部屋のゲーム内容と部屋にいるプレーヤー情報
+// <remark>: 得点の履歴は量が多いため、必要な場合のみ個別に返却する
 type RoomInfoSet struct {
-	GameId  string          `json:"game_id"`
-	Players []PlayerInfoSet `json:"players"`
+	GameId       string             `json:"game_id"`
+	Expansions   []string           `json:"expansions"`
+	HostId       string             `json:"host_id"`
+	IsPrivate    bool               `json:"is_private"`
+	IsLocked     bool               `json:"is_locked"`
+	Players      []PlayerInfoSet    `json:"players"`
+	Spectators   []SpectatorInfoSet `json:"spectators"`
+	Result       ScoreResult        `json:"result"`
+	CreatedAt    time.Time          `json:"created_at"`
+	LastActivity time.Time          `json:"last_activity"`
+	GameData     BgPartialData      `json:"-"`
+	PassHash     string             `json:"-"`
+	History      []ScoreChange      `json:"-"`
+	Redo         []ScoreChange      `json:"-"`
+}
+
+// <summary>: 得点計算の結果
+type ScoreResult struct {
+	Ranking []RankInfo `json:"ranking"`
+}
+
+// <summary>: プレイヤー毎の合計点と順位
+// <remark>: TieBreakには同点の解消に使用した項目名が入る
+type RankInfo struct {
+	PlayerId string `json:"player_id"`
+	Total    int    `json:"total"`
+	Rank     int    `json:"rank"`
+	TieBreak string `json:"tie_break"`
+}
+
+// <summary>: 得点の変更履歴
+type ScoreChange struct {
+	Seq       int       `json:"seq"`
+	PlayerId  string    `json:"player_id"`
+	ChangedAt time.Time `json:"changed_at"`
+	Previous  []int     `json:"previous"`
+	Current   []int     `json:"current"`
 }
 
 // <summary>: WebSocketでの受信用データの構造体
+// <remark>: ConnIdは受信した接続からサーバ側で補完される
+// <remark>: VerifiedHashは受信時に検証した合言葉のハッシュ値で、クライアントからは指定できない
+// <remark>: PassHashは部屋の作成時に受信処理でハッシュ化した合言葉で、クライアントからは指定できない
 type WsRequest struct {
-	Method      string   `json:"method"`
-	ConnId      string   `json:"connection_id"`
-	RoomId      string   `json:"room_id"`
-	GameId      string   `json:"game_id"`
-	PlayerColor string   `json:"player_color"`
-	Points      []int    `json:"points"`
+	Method       string   `json:"method"`
+	ConnId       string   `json:"connection_id"`
+	RoomId       string   `json:"room_id"`
+	GameId       string   `json:"game_id"`
+	PlayerColor  string   `json:"player_color"`
+	Points       []int    `json:"points"`
+	ResumeToken  string   `json:"resume_token"`
+	OwnOnly      bool     `json:"own_only"`
+	Seq          int      `json:"seq"`
+	TargetId     string   `json:"target_player_id"`
+	Passphrase   string   `json:"passphrase"`
+	VerifiedHash string   `json:"-"`
+	PassHash     string   `json:"-"`
+	IsPrivate    bool     `json:"is_private"`
+	Name         string   `json:"name"`
+	Avatar       string   `json:"avatar"`
+	Expansions   []string `json:"expansions"`
+}
+
+// <summary>: 秘匿する項目を伏せた、ログ出力用の文字列を取得します
+// <remark>: ConnId、再開用トークン、合言葉とそのハッシュ値は出力しない
+func (r WsRequest) String() string {
+	type plain WsRequest
+	p := plain(r)
+
+	for _, v := range []*string{&p.ConnId, &p.ResumeToken, &p.Passphrase, &p.VerifiedHash, &p.PassHash} {
+		if *v != "" {
+			*v = "***"
+		}
+	}
+
+	return fmt.Sprintf("%+v", p)
 }
 
 // <summary>: WebSocketからの返却用データの構造体
@@ -30,20 +114,57 @@ type WsResponse struct {
 
 // <summary>: 接続時、Response内のParamsに使用される構造体
 type ConnectResponse struct {
-	ConnId string `json:"connection_id"`
+	ConnId      string `json:"connection_id"`
+	PlayerId    string `json:"player_id"`
+	ResumeToken string `json:"resume_token"`
 }
 
 // <summary>: 部屋の情報伝達時、Response内のParamsに使用される構造体
 type RoomResponse struct {
-	IsWait   bool        `json:"is_wait"`
-	RoomId   string      `json:"room_id"`
-	RoomInfo RoomInfoSet `json:"room"`
+	IsWait   bool          `json:"is_wait"`
+	IsFull   bool          `json:"is_full"`
+	RoomId   string        `json:"room_id"`
+	RoomInfo RoomInfoSet   `json:"room"`
+	History  []ScoreChange `json:"history,omitempty"`
 }
 
 // <summary>: 得点のブロードキャスト時、Response内のParamsに使用される構造体
 type PointResponse struct {
 	Player PlayerInfoSet `json:"player"`
 	Points []int         `json:"points"`
+	Total  int           `json:"total"`
+	Change ScoreChange   `json:"change"`
+	Result ScoreResult   `json:"result"`
+}
+
+// <summary>: 得点の履歴取得時、Response内のParamsに使用される構造体
+type HistoryResponse struct {
+	RoomId  string          `json:"room_id"`
+	GameId  string          `json:"game_id"`
+	Players []PlayerInfoSet `json:"players"`
+	History []ScoreChange   `json:"history"`
+}
+
+// <summary>: 部屋から退室させられた時、Response内のParamsに使用される構造体
+type EjectResponse struct {
+	RoomId string `json:"room_id"`
+	Reason string `json:"reason"`
+}
+
+// <summary>: 色の交換を申し込まれた時、Response内のParamsに使用される構造体
+// <remark>: 申し込まれた側がFromColorを指定してRECOLORを送信すると交換が成立する
+type RecolorOffer struct {
+	RoomId    string `json:"room_id"`
+	FromId    string `json:"from_player_id"`
+	FromColor string `json:"from_color"`
+	ToColor   string `json:"to_color"`
+}
+
+// <summary>: サーバが停止する時、Response内のParamsに使用される構造体
+// <remark>: 入室中のプレイヤーは再起動後にRESUMEで席を再開できる
+type ShutdownResponse struct {
+	Reason    string `json:"reason"`
+	CanResume bool   `json:"can_resume"`
 }
 
 // <summary>: MethodがOKの時、特に伝達する情報がない場合に使用される構造体
@@ -59,21 +180,39 @@ type ErrorMessage struct {
 
 // <summary>: 部屋の存在確認に使用される構造体
 type CheckRoomResult struct {
-	IsExist bool   `json:"is_exist"`
-	GameId  string `json:"game_id"`
+	IsExist  bool   `json:"is_exist"`
+	IsLocked bool   `json:"is_locked"`
+	GameId   string `json:"game_id"`
 }
 
 // <summary>: スコアツール対応のボードゲームデータを格納します
+// <remark>: 拡張であれば、BaseIdに基本となるボードゲームのIDが入る
 type BgPartialData struct {
-	Title      string   `json:"title"`
-	MinPlayers int      `json:"min_players"`
-	MaxPlayers int      `json:"max_players"`
-	Colors     []string `json:"colors"`
+	Title       string          `json:"title"`
+	MinPlayers  int             `json:"min_players"`
+	MaxPlayers  int             `json:"max_players"`
+	IsExpansion bool            `json:"is_expansion"`
+	BaseId      string          `json:"expansion_base_id"`
+	Colors      []string        `json:"colors"`
+	Categories  []ScoreCategory `json:"categories"`
+}
+
+// <summary>: 得点表の項目を格納します
+// <remark>: TieBreakは同点時に比較する優先順位で、0であれば比較に使用しない
+type ScoreCategory struct {
+	Name     string `json:"name"`
+	Min      int    `json:"min"`
+	Max      int    `json:"max"`
+	Weight   int    `json:"weight"`
+	IsTotal  bool   `json:"is_total"`
+	TieBreak int    `json:"tie_break"`
 }
 
 // <summary>: 接続情報を一覧表示するための構造体
 type ConnectionSummary struct {
-	ConnId       string          `json:"connection_id"`
+	PlayerId     string          `json:"player_id"`
+	Name         string          `json:"name"`
+	Avatar       string          `json:"avatar"`
 	RoomId       string          `json:"room_id"`
 	GameId       string          `json:"game_id"`
 	GameData     BgPartialData   `json:"game_data"`
@@ -83,8 +222,116 @@ type ConnectionSummary struct {
 
 // <summary>: 部屋情報を一覧表示するための構造体
 type RoomSummary struct {
-	RoomId   string          `json:"room_id"`
-	GameId   string          `json:"game_id"`
-	GameData BgPartialData   `json:"game_data"`
-	Players  []PlayerInfoSet `json:"players"`
+	RoomId       string             `json:"room_id"`
+	GameId       string             `json:"game_id"`
+	Expansions   []string           `json:"expansions"`
+	GameData     BgPartialData      `json:"game_data"`
+	Players      []PlayerInfoSet    `json:"players"`
+	Spectators   []SpectatorInfoSet `json:"spectators"`
+	CreatedAt    time.Time          `json:"created_at"`
+	LastActivity time.Time          `json:"last_activity"`
+}
+
+// <summary>: 接続の死活監視の状況を表示するための構造体
+type HeartbeatSummary struct {
+	Connections    int   `json:"connections"`
+	Reaped         int64 `json:"reaped"`
+	IdleTimeoutSec int   `json:"idle_timeout_sec"`
+}
+
+// <summary>: 終了したゲームのプレイ記録
+// <remark>: IsHiddenは非公開、もしくは合言葉が必要な部屋の記録であることを示し、一覧や取得の対象から除く
+type PlayRecord struct {
+	PlayId    int64          `json:"play_id"`
+	GameId    string         `json:"game_id"`
+	RoomId    string         `json:"room_id"`
+	StartedAt time.Time      `json:"started_at"`
+	PlayedAt  time.Time      `json:"played_at"`
+	Players   []PlayerRecord `json:"players"`
+	IsHidden  bool           `json:"-"`
+}
+
+// <summary>: プレイ記録に含まれるプレイヤー毎の結果
+type PlayerRecord struct {
+	PlayerId string `json:"player_id"`
+	Name     string `json:"name"`
+	Avatar   string `json:"avatar"`
+	Color    string `json:"player_color"`
+	Points   []int  `json:"points"`
+	Total    int    `json:"total"`
+	Rank     int    `json:"rank"`
+	IsWinner bool   `json:"is_winner"`
+}
+
+// <summary>: プレイ記録の一覧を取得する際の検索条件
+type PlayQuery struct {
+	GameId string
+	Limit  int
+	Offset int
+}
+
+// <summary>: ゲームを終了した時、Response内のParamsに使用される構造体
+type FinishResponse struct {
+	RoomId string     `json:"room_id"`
+	Record PlayRecord `json:"record"`
+}
+
+// <summary>: 管理用APIでボードゲームの情報を色と併せて扱うための構造体
+// <remark>: 更新時にColorsを省略すれば、登録済みの色を変更しない
+type BoardgameDetail struct {
+	MstrBoardgame
+	Colors []string `json:"colors"`
+}
+
+// <summary>: 基本のボードゲームに拡張を入れ子にして返却する際に使用される構造体
+type BoardgameTree struct {
+	BoardgameDetail
+	Expansions []BoardgameDetail `json:"expansions"`
+}
+
+// <summary>: ボードゲームの一覧の1件分の構造体
+// <remark>: 拡張を入れ子にする指定がなければ、Expansionsは出力しない
+type BoardgameNode struct {
+	MstrBoardgame
+	Expansions []MstrBoardgame `json:"expansions,omitempty"`
+}
+
+// <summary>: ボードゲームの一覧を取得する際の検索条件
+// <remark>: Sortには並び替えに使用する列を指定し、Countがtrueであれば件数のみを取得する
+// <remark>: Limitが0であれば全件を取得し、Nestがtrueであれば拡張を基本のボードゲームに入れ子にする
+type BoardgameQuery struct {
+	Players     int
+	Age         int
+	MaxTime     int
+	IsExpansion *bool
+	BaseId      string
+	Sort        string
+	Desc        bool
+	Limit       int
+	Offset      int
+	Count       bool
+	Nest        bool
+}
+
+// <summary>: ボードゲームの一覧を返却する際に使用される構造体
+type BoardgameList struct {
+	Total      int64           `json:"total"`
+	Limit      int             `json:"limit"`
+	Offset     int             `json:"offset"`
+	Boardgames []BoardgameNode `json:"boardgames"`
+}
+
+// <summary>: ボードゲームの検索結果1件分の構造体
+type SearchHit struct {
+	Id          string `json:"id"`
+	UniqueName  string `json:"unique_name"`
+	Title       string `json:"title"`
+	IsExpansion bool   `json:"is_expansion"`
+	Score       int    `json:"score"`
+}
+
+// <summary>: ボードゲームの検索結果を返却する際に使用される構造体
+type SearchResult struct {
+	Query string      `json:"query"`
+	Hits  []SearchHit `json:"hits"`
 }
@@ -24,6 +24,24 @@ var ErrNotInRoom = ErrorMessage{
 	Message: "指定された部屋にまだ入室していません",
 }
 
+// <summary>: 【エラー】再開できる席が存在しません
+var ErrSeatNotFound = ErrorMessage{
+	Error: "E005",
+	Message: "再開できる席が存在しません",
+}
+
+// <summary>: 【エラー】対象のプレイヤーが部屋に存在しません
+var ErrPlayerNotFound = ErrorMessage{
+	Error: "E006",
+	Message: "指定されたプレイヤーは部屋に存在しません",
+}
+
+// <summary>: 【エラー】対象のプレイ記録が存在しません
+var ErrPlayNotFound = ErrorMessage{
+	Error: "E007",
+	Message: "指定されたプレイ記録は存在しません",
+}
+
 // <summary>: 【エラー】無効なメソッド
 var ErrInvalidMethod = ErrorMessage{
 	Error: "E101",
@@ -36,6 +54,66 @@ var ErrIllegalConnId = ErrorMessage{
 	Message: "不正なconnection_idが検知されました",
 }
 
+// <summary>: 【エラー】得点が得点表の定義に合致しない
+var ErrInvalidPoints = ErrorMessage{
+	Error: "E103",
+	Message: "得点の内容がボードゲームの得点表と一致しません",
+}
+
+// <summary>: 【エラー】表示名、もしくはアバターが不正
+var ErrInvalidName = ErrorMessage{
+	Error: "E104",
+	Message: "表示名、もしくはアバターに使用できない文字や長さが含まれています",
+}
+
+// <summary>: 【エラー】リクエストの形式が不正
+var ErrMalformedRequest = ErrorMessage{
+	Error: "E105",
+	Message: "リクエストをJSONとして解析できませんでした",
+}
+
+// <summary>: 【エラー】検索条件が不正
+var ErrInvalidQuery = ErrorMessage{
+	Error: "E106",
+	Message: "検索条件の指定が不正です",
+}
+
+// <summary>: 【エラー】ボードゲームの情報が不正
+var ErrInvalidBoardgame = ErrorMessage{
+	Error: "E107",
+	Message: "ボードゲームの情報に不正な項目が含まれています",
+}
+
+// <summary>: 【エラー】プレイ人数の範囲が不正
+var ErrInvalidPlayerRange = ErrorMessage{
+	Error: "E108",
+	Message: "最少プレイ人数が最大プレイ人数を超えています",
+}
+
+// <summary>: 【エラー】得点計算に必要な色が足りない
+var ErrNotEnoughColors = ErrorMessage{
+	Error: "E109",
+	Message: "得点計算に対応するには最大プレイ人数以上の色が必要です",
+}
+
+// <summary>: 【エラー】拡張の指定に誤りがある
+var ErrInvalidExpansion = ErrorMessage{
+	Error: "E110",
+	Message: "指定された拡張はボードゲームに対応していません",
+}
+
+// <summary>: 【エラー】合言葉が長すぎる
+var ErrInvalidPassphrase = ErrorMessage{
+	Error: "E111",
+	Message: "合言葉は72バイト以内で指定してください",
+}
+
+// <summary>: 【エラー】部屋IDが不正である
+var ErrInvalidRoomId = ErrorMessage{
+	Error: "E112",
+	Message: "部屋IDに使用できない文字や長さが含まれています",
+}
+
 // <summary>: 【エラー】別室へ既に入室している
 var ErrEnteredAnotherRoom = ErrorMessage{
 	Error: "E201",
@@ -59,3 +137,117 @@ var ErrSameColorExistedInRoom = ErrorMessage{
 	Error: "E204",
 	Message: "指定された部屋には既に同色のプレイヤーが入室しています",
 }
+
+// <summary>: 【エラー】部屋が満員です
+var ErrRoomFull = ErrorMessage{
+	Error: "E205",
+	Message: "指定された部屋は既に満員です",
+}
+
+// <summary>: 【エラー】ボードゲームで使用できない色が指定された
+var ErrColorNotOffered = ErrorMessage{
+	Error: "E206",
+	Message: "指定された色はこのボードゲームでは使用できません",
+}
+
+// <summary>: 【エラー】取り消せる得点の変更がない
+var ErrNothingToUndo = ErrorMessage{
+	Error: "E207",
+	Message: "取り消せる得点の変更がありません",
+}
+
+// <summary>: 【エラー】やり直せる得点の変更がない
+var ErrNothingToRedo = ErrorMessage{
+	Error: "E208",
+	Message: "やり直せる得点の変更がありません",
+}
+
+// <summary>: 【エラー】得点の履歴が他のプレイヤーによって更新されている
+var ErrScoreConflict = ErrorMessage{
+	Error: "E209",
+	Message: "得点の履歴が他のプレイヤーによって更新されています",
+}
+
+// <summary>: 【エラー】部屋のホスト以外が操作しようとしている
+var ErrNotHost = ErrorMessage{
+	Error: "E210",
+	Message: "この操作は部屋のホストのみが実行できます",
+}
+
+// <summary>: 【エラー】ホストが自身を退室させようとしている
+var ErrEjectSelf = ErrorMessage{
+	Error: "E211",
+	Message: "ホスト自身を退室させることはできません",
+}
+
+// <summary>: 【エラー】部屋IDを払い出せない
+var ErrRoomIdUnavailable = ErrorMessage{
+	Error: "E212",
+	Message: "部屋IDを払い出せませんでした",
+}
+
+// <summary>: 【エラー】部屋への入室に合言葉が必要
+var ErrPassphraseRequired = ErrorMessage{
+	Error: "E213",
+	Message: "指定された部屋への入室には合言葉が必要です",
+}
+
+// <summary>: 【エラー】合言葉が一致しない
+var ErrWrongPassphrase = ErrorMessage{
+	Error: "E214",
+	Message: "合言葉が一致しません",
+}
+
+// <summary>: 【エラー】部屋に同じ表示名のプレイヤーがいる
+var ErrNameTaken = ErrorMessage{
+	Error: "E215",
+	Message: "指定された部屋には既に同じ表示名のプレイヤーが入室しています",
+}
+
+// <summary>: 【エラー】ボードゲームが既に存在します
+var ErrBoardgameExisted = ErrorMessage{
+	Error: "E216",
+	Message: "指定されたボードゲームは既に存在しています",
+}
+
+// <summary>: 【エラー】ボードゲームに同じ色が既に存在します
+var ErrColorExisted = ErrorMessage{
+	Error: "E217",
+	Message: "指定された色は既にボードゲームに登録されています",
+}
+
+// <summary>: 【エラー】部屋の処理待ちが溢れている
+var ErrRoomBusy = ErrorMessage{
+	Error: "E218",
+	Message: "部屋が混み合っているため、リクエストを処理できませんでした",
+}
+
+// <summary>: 【エラー】合言葉の誤りが続いている
+var ErrTooManyAttempts = ErrorMessage{
+	Error: "E219",
+	Message: "合言葉の誤りが続いたため、しばらく時間をおいてから再度お試しください",
+}
+
+// <summary>: 【エラー】プレイ記録の保存中である
+var ErrFinishing = ErrorMessage{
+	Error: "E220",
+	Message: "プレイ記録の保存中のため、リクエストを処理できません",
+}
+
+// <summary>: 【エラー】ボードゲームに拡張が登録されている
+var ErrHasExpansions = ErrorMessage{
+	Error: "E221",
+	Message: "拡張が登録されているボードゲームは、削除や拡張への変更ができません",
+}
+
+// <summary>: 【エラー】プレイ記録を保存できなかった
+var ErrPlayNotSaved = ErrorMessage{
+	Error: "E301",
+	Message: "プレイ記録の保存に失敗しました",
+}
+
+// <summary>: 【エラー】管理用APIの認証に失敗した
+var ErrUnauthorized = ErrorMessage{
+	Error: "E401",
+	Message: "認証に失敗しました",
+}
@@ -9,6 +9,15 @@ const (
 	CREATE    Method = "CREATE"
 	JOIN      Method = "JOIN"
 	LEAVE     Method = "LEAVE"
+	RESUME    Method = "RESUME"
+	HISTORY   Method = "HISTORY"
+	UNDO      Method = "UNDO"
+	REDO      Method = "REDO"
+	WATCH     Method = "WATCH"
+	RENAME    Method = "RENAME"
+	RECOLOR   Method = "RECOLOR"
+	FINISH    Method = "FINISH"
+	SHUTDOWN  Method = "SHUTDOWN"
 
 	NONE       Method = "NONE"
 	CONNECT    Method = "CONNECT"
@@ -39,6 +48,33 @@ func ParseMethod(s string) (m Method) {
 	case "LEAVE":
 		m = LEAVE
 
+	case "RESUME":
+		m = RESUME
+
+	case "HISTORY":
+		m = HISTORY
+
+	case "UNDO":
+		m = UNDO
+
+	case "REDO":
+		m = REDO
+
+	case "WATCH":
+		m = WATCH
+
+	case "RENAME":
+		m = RENAME
+
+	case "RECOLOR":
+		m = RECOLOR
+
+	case "FINISH":
+		m = FINISH
+
+	case "SHUTDOWN":
+		m = SHUTDOWN
+
 	case "CONNECT":
 		m = CONNECT
 
@@ -0,0 +1,211 @@
+package score
+
+import (
+	"sort"
+	"sync"
+
+	"bgtools-api/models"
+)
+
+// <summary>: ボードゲーム毎の得点計算を定義するインターフェース
+// <remark>: 部屋の得点が変更される度に、部屋にいる全プレイヤー分がまとめて渡される
+type Calculator interface {
+	Calculate(cats []models.ScoreCategory, players []models.PlayerInfoSet) models.ScoreResult
+}
+
+// <summary>: 関数をCalculatorとして扱うための型
+type CalculatorFunc func(cats []models.ScoreCategory, players []models.PlayerInfoSet) models.ScoreResult
+
+// <summary>: 得点計算を実行します
+func (f CalculatorFunc) Calculate(cats []models.ScoreCategory, players []models.PlayerInfoSet) models.ScoreResult {
+	return f(cats, players)
+}
+
+var (
+	// <summary>: ボードゲームのIDと得点計算の紐付け
+	calculators = map[string]Calculator{}
+	mu          sync.RWMutex
+
+	// <summary>: 個別の得点計算が登録されていない場合に使用されます
+	Default Calculator = Standard{}
+)
+
+// <summary>: ボードゲームのID（M_BOARDGAME.id）に得点計算を登録します
+func Register(gameid string, c Calculator) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	calculators[gameid] = c
+}
+
+// <summary>: ボードゲームのIDに登録された得点計算を削除します
+// <remark>: 削除後はDefaultが使用される
+func Unregister(gameid string) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	delete(calculators, gameid)
+}
+
+// <summary>: ボードゲームのIDに登録された得点計算を取得します
+// <remark>: 登録されていなければDefaultを返す
+func Lookup(gameid string) Calculator {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	if c, ok := calculators[gameid]; ok {
+		return c
+	}
+
+	return Default
+}
+
+// <summary>: 得点表の重みで合算し、合計点の順に順位を付ける標準の得点計算
+// <remark>: TieBreakersに指定した項目の順に比較して同点を解消する
+// <remark>: LowerIsBetterであれば、合計点、同点時の項目ともに小さい方を上位とする
+type Standard struct {
+	LowerIsBetter bool
+	TieBreakers   []int
+}
+
+// <summary>: 得点計算を実行します
+func (s Standard) Calculate(cats []models.ScoreCategory, players []models.PlayerInfoSet) models.ScoreResult {
+	ranking := make([]models.RankInfo, len(players))
+
+	for i, p := range players {
+		ranking[i] = models.RankInfo{
+			PlayerId: p.PlayerId,
+			Total:    WeightedSum(cats, p.Points),
+		}
+	}
+
+	better := func(a, b int) bool {
+		if s.LowerIsBetter {
+			return a < b
+		}
+
+		return a > b
+	}
+
+	points := make(map[string][]int, len(players))
+	for _, p := range players {
+		points[p.PlayerId] = p.Points
+	}
+
+	tiebreak := func(a, b models.RankInfo) (int, string) {
+		for _, idx := range s.TieBreakers {
+			pa, pb := at(points[a.PlayerId], idx), at(points[b.PlayerId], idx)
+
+			if pa == pb {
+				continue
+			}
+
+			name := ""
+			if idx < len(cats) {
+				name = cats[idx].Name
+			}
+
+			if better(pa, pb) {
+				return -1, name
+			}
+
+			return 1, name
+		}
+
+		return 0, ""
+	}
+
+	return Rank(ranking, better, tiebreak)
+}
+
+// <summary>: 得点表の定義から、同点時に比較する項目の位置を優先順位の順に取得します
+// <remark>: 優先順位が同じであれば、得点表の順に並べる
+func TieBreakers(cats []models.ScoreCategory) []int {
+	idx := []int{}
+
+	for i, c := range cats {
+		if c.TieBreak > 0 {
+			idx = append(idx, i)
+		}
+	}
+
+	sort.SliceStable(idx, func(i, j int) bool {
+		return cats[idx[i]].TieBreak < cats[idx[j]].TieBreak
+	})
+
+	return idx
+}
+
+// <summary>: 得点表の定義をもとに合計点を計算します
+// <remark>: 得点表が定義されていないボードゲームでは単純に合算する
+func WeightedSum(cats []models.ScoreCategory, points []int) int {
+	total := 0
+
+	for i, p := range points {
+		if len(cats) == 0 {
+			total += p
+			continue
+		}
+
+		if i < len(cats) && cats[i].IsTotal {
+			total += p * cats[i].Weight
+		}
+	}
+
+	return total
+}
+
+// <summary>: 合計点をもとに順位を付けます
+// <remark>: tiebreakは同点時に呼ばれ、負数ならa、正数ならbを上位とし、解消に使用した項目名を返す
+func Rank(ranking []models.RankInfo, better func(a, b int) bool,
+	tiebreak func(a, b models.RankInfo) (int, string)) models.ScoreResult {
+
+	sort.SliceStable(ranking, func(i, j int) bool {
+		a, b := ranking[i], ranking[j]
+
+		if a.Total != b.Total {
+			return better(a.Total, b.Total)
+		}
+
+		r, _ := tiebreak(a, b)
+		return r < 0
+	})
+
+	for i := range ranking {
+		if i == 0 {
+			ranking[i].Rank = 1
+			continue
+		}
+
+		prev := ranking[i-1]
+
+		if prev.Total != ranking[i].Total {
+			ranking[i].Rank = i + 1
+			continue
+		}
+
+		r, by := tiebreak(prev, ranking[i])
+
+		if r == 0 {
+			ranking[i].Rank = prev.Rank
+
+		} else {
+			ranking[i].Rank = i + 1
+			ranking[i-1].TieBreak = by
+			ranking[i].TieBreak = by
+		}
+	}
+
+	return models.ScoreResult{
+		Ranking: ranking,
+	}
+}
+
+// <summary>: 得点の配列から安全に値を取り出します
+func at(points []int, i int) int {
+	if i < 0 || len(points) <= i {
+		return 0
+	}
+
+	return points[i]
+}
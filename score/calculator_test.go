@@ -0,0 +1,143 @@
+package score
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"bgtools-api/models"
+)
+
+// <summary>: 順位表を比較しやすい文字列の一覧にします
+func summarize(r models.ScoreResult) []string {
+	rv := make([]string, 0, len(r.Ranking))
+
+	for _, ri := range r.Ranking {
+		rv = append(rv, fmt.Sprintf("%s:%d:%d:%s", ri.PlayerId, ri.Total, ri.Rank, ri.TieBreak))
+	}
+
+	return rv
+}
+
+func TestStandardCalculate(t *testing.T) {
+	cats := []models.ScoreCategory{
+		{Name: "点数", Weight: 1, IsTotal: true},
+		{Name: "資源", Weight: 1, IsTotal: false},
+		{Name: "手番", Weight: 1, IsTotal: false},
+	}
+
+	players := func(points ...[]int) []models.PlayerInfoSet {
+		rv := make([]models.PlayerInfoSet, 0, len(points))
+
+		for i, p := range points {
+			rv = append(rv, models.PlayerInfoSet{
+				PlayerId: fmt.Sprintf("p%d", i+1),
+				Points:   p,
+			})
+		}
+
+		return rv
+	}
+
+	tests := []struct {
+		name    string
+		calc    Standard
+		players []models.PlayerInfoSet
+		want    []string
+	}{
+		{
+			name:    "合計点の高い順",
+			calc:    Standard{},
+			players: players([]int{3, 0, 0}, []int{5, 0, 0}, []int{4, 0, 0}),
+			want:    []string{"p2:5:1:", "p3:4:2:", "p1:3:3:"},
+		},
+		{
+			name:    "合計点の低い順",
+			calc:    Standard{LowerIsBetter: true},
+			players: players([]int{3, 0, 0}, []int{5, 0, 0}, []int{4, 0, 0}),
+			want:    []string{"p1:3:1:", "p3:4:2:", "p2:5:3:"},
+		},
+		{
+			name:    "解消できない同点は同順位とし、次の順位を飛ばす",
+			calc:    Standard{},
+			players: players([]int{5, 1, 0}, []int{5, 2, 0}, []int{4, 0, 0}),
+			want:    []string{"p1:5:1:", "p2:5:1:", "p3:4:3:"},
+		},
+		{
+			name:    "同点は指定した項目で解消する",
+			calc:    Standard{TieBreakers: []int{1}},
+			players: players([]int{5, 1, 0}, []int{5, 2, 0}, []int{4, 0, 0}),
+			want:    []string{"p2:5:1:資源", "p1:5:2:資源", "p3:4:3:"},
+		},
+		{
+			name:    "同点の項目も一致すれば次の項目で解消する",
+			calc:    Standard{TieBreakers: []int{1, 2}},
+			players: players([]int{5, 2, 1}, []int{5, 2, 3}),
+			want:    []string{"p2:5:1:手番", "p1:5:2:手番"},
+		},
+		{
+			name:    "合計点の低い順では、同点の項目も低い方を上位とする",
+			calc:    Standard{LowerIsBetter: true, TieBreakers: []int{1}},
+			players: players([]int{5, 2, 0}, []int{5, 1, 0}, []int{6, 0, 0}),
+			want:    []string{"p2:5:1:資源", "p1:5:2:資源", "p3:6:3:"},
+		},
+		{
+			name:    "得点表にない項目は0として比較する",
+			calc:    Standard{TieBreakers: []int{5}},
+			players: players([]int{5, 0, 0}, []int{5, 0, 0}),
+			want:    []string{"p1:5:1:", "p2:5:1:"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := summarize(tt.calc.Calculate(cats, tt.players))
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Calculate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWeightedSum(t *testing.T) {
+	tests := []struct {
+		name   string
+		cats   []models.ScoreCategory
+		points []int
+		want   int
+	}{
+		{"得点表がなければ単純に合算する", nil, []int{1, 2, 3}, 6},
+		{"重みを掛けて合算する", []models.ScoreCategory{{Weight: 2, IsTotal: true}, {Weight: 3, IsTotal: true}}, []int{1, 2}, 8},
+		{"合計に含めない項目は除く", []models.ScoreCategory{{Weight: 1, IsTotal: true}, {Weight: 1}}, []int{4, 9}, 4},
+		{"得点表より多い得点は除く", []models.ScoreCategory{{Weight: 1, IsTotal: true}}, []int{4, 9}, 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := WeightedSum(tt.cats, tt.points); got != tt.want {
+				t.Errorf("WeightedSum() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTieBreakers(t *testing.T) {
+	tests := []struct {
+		name string
+		cats []models.ScoreCategory
+		want []int
+	}{
+		{"指定がなければ空", []models.ScoreCategory{{}, {}}, []int{}},
+		{"優先順位の順に並べる", []models.ScoreCategory{{TieBreak: 2}, {}, {TieBreak: 1}}, []int{2, 0}},
+		{"優先順位が同じであれば得点表の順", []models.ScoreCategory{{TieBreak: 1}, {TieBreak: 1}}, []int{0, 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := TieBreakers(tt.cats); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("TieBreakers() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
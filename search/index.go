@@ -0,0 +1,261 @@
+package search
+
+import (
+	"sort"
+	"strings"
+	"sync/atomic"
+	"unicode/utf8"
+
+	"bgtools-api/models"
+)
+
+const (
+	// 完全一致した時のスコア
+	scoreExact int = 100
+
+	// 前方一致した時のスコア
+	scorePrefix int = 80
+
+	// 単語の先頭に一致した時のスコア
+	scoreWordPrefix int = 60
+
+	// 部分一致した時のスコア
+	scoreSubstring int = 40
+
+	// あいまい一致した時のスコア（編集距離1毎に減点する）
+	scoreFuzzy int = 20
+
+	// あいまい一致で編集距離1毎に減らすスコア
+	fuzzyPenalty int = 5
+)
+
+// <summary>: 検索用に正規化したボードゲームの情報
+type entry struct {
+	game models.MstrBoardgame
+	keys []string
+}
+
+// <summary>: ボードゲームの検索用索引
+// <remark>: 構築後は変更せず、読み込み直す際は丸ごと差し替える
+type Index struct {
+	entries []entry
+}
+
+// <summary>: 現在の検索用索引
+var current atomic.Value
+
+func init() {
+	current.Store(NewIndex(nil))
+}
+
+// <summary>: 現在の検索用索引を取得します
+func Current() *Index {
+	return current.Load().(*Index)
+}
+
+// <summary>: 検索用索引を差し替えます
+func Store(x *Index) {
+	current.Store(x)
+}
+
+// <summary>: ボードゲームの一覧から検索用索引を構築します
+// <remark>: タイトルとユニーク名を正規化し、空白を除いた形も併せて索引に含める
+func NewIndex(games []models.MstrBoardgame) *Index {
+	x := &Index{entries: make([]entry, 0, len(games))}
+
+	for _, g := range games {
+		keys := []string{}
+
+		for _, s := range []string{g.Title, g.UniqueName} {
+			k := Normalize(s)
+			if k == "" {
+				continue
+			}
+
+			keys = appendUnique(keys, k)
+			keys = appendUnique(keys, strings.ReplaceAll(k, " ", ""))
+		}
+
+		if len(keys) == 0 {
+			continue
+		}
+
+		x.entries = append(x.entries, entry{game: g, keys: keys})
+	}
+
+	return x
+}
+
+// <summary>: 索引に含まれるボードゲームの件数を取得します
+func (x *Index) Len() int {
+	return len(x.entries)
+}
+
+// <summary>: 検索語に一致するボードゲームを関連度の高い順に最大limit件取得します
+// <remark>: 関連度が同じであればタイトルの短い順、次にIdの順に並べる
+func (x *Index) Search(query string, limit int) []models.SearchHit {
+	hits := []models.SearchHit{}
+
+	q := Normalize(query)
+	if q == "" || limit <= 0 {
+		return hits
+	}
+
+	compact := strings.ReplaceAll(q, " ", "")
+
+	for _, e := range x.entries {
+		best := 0
+
+		for _, k := range e.keys {
+			if s := match(k, q); s > best {
+				best = s
+			}
+
+			if s := match(k, compact); s > best {
+				best = s
+			}
+		}
+
+		if best == 0 {
+			continue
+		}
+
+		hits = append(hits, models.SearchHit{
+			Id:          e.game.Id,
+			UniqueName:  e.game.UniqueName,
+			Title:       e.game.Title,
+			IsExpansion: e.game.IsExpansion,
+			Score:       best,
+		})
+	}
+
+	sort.SliceStable(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+
+		li := utf8.RuneCountInString(hits[i].Title)
+		lj := utf8.RuneCountInString(hits[j].Title)
+		if li != lj {
+			return li < lj
+		}
+
+		return hits[i].Id < hits[j].Id
+	})
+
+	if len(hits) > limit {
+		hits = hits[:limit]
+	}
+
+	return hits
+}
+
+// <summary>: 正規化済みの索引と検索語を照合し、スコアを返します
+// <remark>: 一致しなければ0を返す
+func match(key string, q string) int {
+	switch {
+	case key == q:
+		return scoreExact
+
+	case strings.HasPrefix(key, q):
+		return scorePrefix
+
+	case strings.Contains(key, " "+q):
+		return scoreWordPrefix
+
+	case strings.Contains(key, q):
+		return scoreSubstring
+	}
+
+	// 入力途中の語を想定し、索引の先頭と編集距離で比較する
+	qr := []rune(q)
+	tol := tolerance(len(qr))
+	if tol == 0 {
+		return 0
+	}
+
+	kr := []rune(key)
+	best := -1
+
+	for _, n := range []int{len(qr) - 1, len(qr), len(qr) + 1} {
+		if n <= 0 || n > len(kr) {
+			continue
+		}
+
+		if d := distance(kr[:n], qr); d <= tol && (best < 0 || d < best) {
+			best = d
+		}
+	}
+
+	if best < 0 {
+		return 0
+	}
+
+	return scoreFuzzy - fuzzyPenalty*best
+}
+
+// <summary>: 検索語の長さに応じて、あいまい一致で許容する編集距離を返します
+func tolerance(n int) int {
+	switch {
+	case n < 3:
+		return 0
+
+	case n < 6:
+		return 1
+
+	default:
+		return 2
+	}
+}
+
+// <summary>: 2つの文字列の編集距離（レーベンシュタイン距離）を求めます
+func distance(a []rune, b []rune) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+
+			cur[j] = min3(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+
+		prev, cur = cur, prev
+	}
+
+	return prev[len(b)]
+}
+
+// <summary>: 3つの値のうち最小の値を返します
+func min3(a int, b int, c int) int {
+	m := a
+	if b < m {
+		m = b
+	}
+
+	if c < m {
+		m = c
+	}
+
+	return m
+}
+
+// <summary>: 重複しない場合のみ要素を追加します
+func appendUnique(list []string, s string) []string {
+	for _, v := range list {
+		if v == s {
+			return list
+		}
+	}
+
+	return append(list, s)
+}
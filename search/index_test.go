@@ -0,0 +1,81 @@
+package search
+
+import (
+	"testing"
+
+	"bgtools-api/models"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		name string
+		key  string
+		q    string
+		want int
+	}{
+		{"完全一致", "katan", "katan", scoreExact},
+		{"前方一致", "carcassonne", "carc", scorePrefix},
+		{"単語の先頭に一致", "ticket to ride", "ride", scoreWordPrefix},
+		{"部分一致", "dominion", "mini", scoreSubstring},
+		{"編集距離1", "dominion", "dominlon", scoreFuzzy - fuzzyPenalty},
+		{"編集距離2", "dominion", "dmoinion", scoreFuzzy - fuzzyPenalty*2},
+		{"入力途中の語", "carcassonne", "carcs", scoreFuzzy - fuzzyPenalty},
+		{"許容する編集距離を超える", "dominion", "dmoniino", 0},
+		{"短い語はあいまい一致しない", "katan", "kz", 0},
+		{"一致しない", "dominion", "azul", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := match(tt.key, tt.q); got != tt.want {
+				t.Errorf("match(%q, %q) = %d, want %d", tt.key, tt.q, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSearch(t *testing.T) {
+	x := NewIndex([]models.MstrBoardgame{
+		{Id: "g1", Title: "カタン", UniqueName: "Catan"},
+		{Id: "g2", Title: "ドミニオン", UniqueName: "Dominion"},
+		{Id: "g3", Title: "ドミニオン：陰謀", UniqueName: "Dominion: Intrigue", IsExpansion: true},
+		{Id: "g4", Title: "カルカソンヌ", UniqueName: "Carcassonne"},
+	})
+
+	tests := []struct {
+		name  string
+		q     string
+		limit int
+		want  []string
+	}{
+		{"ひらがなで検索", "かたん", 10, []string{"g1"}},
+		{"長音の表記揺れ", "カターン", 10, []string{"g1"}},
+		{"英語名で検索", "carcassonne", 10, []string{"g4"}},
+		{"完全一致を先頭にする", "ドミニオン", 10, []string{"g2", "g3"}},
+		{"件数の上限", "dominion", 1, []string{"g2"}},
+		{"綴りの誤り", "dominlon", 10, []string{"g2", "g3"}},
+		{"空の検索語", "", 10, []string{}},
+		{"一致しない", "azul", 10, []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hits := x.Search(tt.q, tt.limit)
+
+			got := make([]string, 0, len(hits))
+			for _, h := range hits {
+				got = append(got, h.Id)
+			}
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("Search(%q) = %v, want %v", tt.q, got, tt.want)
+			}
+
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("Search(%q) = %v, want %v", tt.q, got, tt.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,218 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+)
+
+// <summary>: 半角カタカナ（U+FF66〜U+FF9D）に対応する全角カタカナ
+const halfwidthKana string = "ヲァィゥェォャュョッーアイウエオカキクケコサシスセソタチツテトナニヌネノハヒフヘホマミムメモヤユヨラリルレロワン"
+
+var (
+	// <summary>: ひらがな1文字のローマ字表記（ヘボン式）
+	romaji = map[rune]string{
+		'あ': "a", 'い': "i", 'う': "u", 'え': "e", 'お': "o",
+		'か': "ka", 'き': "ki", 'く': "ku", 'け': "ke", 'こ': "ko",
+		'が': "ga", 'ぎ': "gi", 'ぐ': "gu", 'げ': "ge", 'ご': "go",
+		'さ': "sa", 'し': "shi", 'す': "su", 'せ': "se", 'そ': "so",
+		'ざ': "za", 'じ': "ji", 'ず': "zu", 'ぜ': "ze", 'ぞ': "zo",
+		'た': "ta", 'ち': "chi", 'つ': "tsu", 'て': "te", 'と': "to",
+		'だ': "da", 'ぢ': "ji", 'づ': "zu", 'で': "de", 'ど': "do",
+		'な': "na", 'に': "ni", 'ぬ': "nu", 'ね': "ne", 'の': "no",
+		'は': "ha", 'ひ': "hi", 'ふ': "fu", 'へ': "he", 'ほ': "ho",
+		'ば': "ba", 'び': "bi", 'ぶ': "bu", 'べ': "be", 'ぼ': "bo",
+		'ぱ': "pa", 'ぴ': "pi", 'ぷ': "pu", 'ぺ': "pe", 'ぽ': "po",
+		'ま': "ma", 'み': "mi", 'む': "mu", 'め': "me", 'も': "mo",
+		'や': "ya", 'ゆ': "yu", 'よ': "yo",
+		'ら': "ra", 'り': "ri", 'る': "ru", 'れ': "re", 'ろ': "ro",
+		'わ': "wa", 'ゐ': "i", 'ゑ': "e", 'を': "o", 'ん': "n",
+		'ゔ': "vu",
+	}
+
+	// <summary>: 小書きのひらがな（拗音、外来音）のローマ字表記
+	smallKana = map[rune]string{
+		'ぁ': "a", 'ぃ': "i", 'ぅ': "u", 'ぇ': "e", 'ぉ': "o",
+		'ゃ': "ya", 'ゅ': "yu", 'ょ': "yo", 'ゎ': "wa",
+	}
+
+	// <summary>: 表記揺れとして同一視する長音の綴り
+	// <remark>: 英語などの綴りを変えないよう、仮名から変換した綴りにのみ適用する
+	longVowels = strings.NewReplacer(
+		"ou", "o", "aa", "a", "ii", "i", "uu", "u", "ee", "e", "oo", "o",
+	)
+)
+
+// <summary>: 検索用に文字列を正規化します
+// <remark>: 全角半角、大文字小文字、ひらがなカタカナ、仮名とローマ字の違いを吸収する
+// <remark>: 長音の表記揺れは、仮名から変換した綴りでのみ吸収する
+func Normalize(s string) string {
+	s = toRomaji(foldWidth(s))
+
+	var b strings.Builder
+	space := true
+
+	// 記号は空白とみなし、連続する空白は一つにまとめる
+	for _, r := range s {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(unicode.ToLower(r))
+			space = false
+
+		} else if !space {
+			b.WriteRune(' ')
+			space = true
+		}
+	}
+
+	return strings.TrimSpace(b.String())
+}
+
+// <summary>: 全角英数字と半角カタカナを、半角英数字と全角カタカナに揃えます
+func foldWidth(s string) string {
+	rs := make([]rune, 0, len(s))
+
+	for _, r := range s {
+		switch {
+		case 0xFF01 <= r && r <= 0xFF5E:
+			rs = append(rs, r-0xFEE0)
+
+		case r == 0x3000:
+			rs = append(rs, ' ')
+
+		case 0xFF66 <= r && r <= 0xFF9D:
+			rs = append(rs, []rune(halfwidthKana)[r-0xFF66])
+
+		case r == 0xFF9E || r == 0x3099 || r == 0x309B:
+			// 濁点は直前の文字と結合する
+			if n := len(rs); n > 0 {
+				rs[n-1] = voiced(rs[n-1])
+			}
+
+		case r == 0xFF9F || r == 0x309A || r == 0x309C:
+			// 半濁点は直前の文字と結合する
+			if n := len(rs); n > 0 {
+				rs[n-1] = semiVoiced(rs[n-1])
+			}
+
+		default:
+			rs = append(rs, r)
+		}
+	}
+
+	return string(rs)
+}
+
+// <summary>: 濁音にできる仮名であれば濁音に変換します
+func voiced(r rune) rune {
+	if r == 'ウ' {
+		return 'ヴ'
+	}
+
+	return shiftKana(r, "カキクケコサシスセソタチツテトハヒフヘホ", 1)
+}
+
+// <summary>: 半濁音にできる仮名であれば半濁音に変換します
+func semiVoiced(r rune) rune {
+	return shiftKana(r, "ハヒフヘホ", 2)
+}
+
+// <summary>: カタカナでtargetsに含まれる文字であれば、コードポイントをずらします
+// <remark>: ひらがなはカタカナとして判定し、ひらがなのまま返す
+func shiftKana(r rune, targets string, d rune) rune {
+	hira := 'ぁ' <= r && r <= 'ゖ'
+
+	k := r
+	if hira {
+		k += 0x60
+	}
+
+	if !strings.ContainsRune(targets, k) {
+		return r
+	}
+
+	return r + d
+}
+
+// <summary>: カタカナをひらがなにした上で、仮名をローマ字に変換します
+// <remark>: 仮名以外の文字はそのまま残し、仮名から変換した綴りは長音の表記揺れを吸収する
+func toRomaji(s string) string {
+	var b strings.Builder
+	var kana strings.Builder
+	double := false
+
+	// 連続する仮名から変換した綴りを、長音を揃えて書き出す
+	flush := func() {
+		b.WriteString(longVowels.Replace(kana.String()))
+		kana.Reset()
+	}
+
+	for _, r := range s {
+		// カタカナはひらがなとして扱う
+		if 'ァ' <= r && r <= 'ヶ' {
+			r -= 0x60
+		}
+
+		if r == 'っ' {
+			double = true
+			continue
+		}
+
+		// 長音符は表記揺れとみなして読み飛ばす
+		if r == 'ー' {
+			continue
+		}
+
+		if small, ok := smallKana[r]; ok {
+			kana.WriteString(combine(&kana, small))
+			continue
+		}
+
+		roma, ok := romaji[r]
+		if !ok {
+			flush()
+			b.WriteRune(r)
+			double = false
+			continue
+		}
+
+		// 促音は次の子音を重ねる
+		if double {
+			if strings.HasPrefix(roma, "ch") {
+				kana.WriteByte('t')
+
+			} else if c := roma[0]; !strings.ContainsRune("aiueo", rune(c)) {
+				kana.WriteByte(c)
+			}
+
+			double = false
+		}
+
+		kana.WriteString(roma)
+	}
+
+	flush()
+
+	return b.String()
+}
+
+// <summary>: 小書きの仮名を直前の仮名と結合します
+// <remark>: 直前の母音を取り除いた上で、続けて書き込む綴りを返す
+func combine(b *strings.Builder, small string) string {
+	prev := b.String()
+
+	if prev == "" || !strings.ContainsRune("aiueo", rune(prev[len(prev)-1])) {
+		return small
+	}
+
+	stem := prev[:len(prev)-1]
+	b.Reset()
+	b.WriteString(stem)
+
+	// しゃ、ちゃ、じゃはyを重ねない
+	if len(small) == 2 && small[0] == 'y' &&
+		(strings.HasSuffix(stem, "sh") || strings.HasSuffix(stem, "ch") || strings.HasSuffix(stem, "j")) {
+
+		return small[1:]
+	}
+
+	return small
+}
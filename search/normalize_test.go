@@ -0,0 +1,40 @@
+package search
+
+import (
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"英字は小文字に揃える", "Carcassonne", "carcassonne"},
+		{"全角英字", "ＣＡＲＣＡＳＳＯＮＮＥ", "carcassonne"},
+		{"記号は空白にまとめる", "Ticket to Ride: Europe", "ticket to ride europe"},
+		{"記号と空白のみ", "  --  ", ""},
+		{"空文字", "", ""},
+		{"英字の重なる母音は変えない", "Root", "root"},
+		{"英字のouは変えない", "Cloud Kingdom", "cloud kingdom"},
+		{"カタカナ", "カタン", "katan"},
+		{"ひらがな", "かたん", "katan"},
+		{"半角カタカナ", "ｶﾀﾝ", "katan"},
+		{"半角カタカナの濁点", "ｶﾞｲｽﾀｰ", "gaisuta"},
+		{"長音符は読み飛ばす", "カターン", "katan"},
+		{"仮名の長音を揃える", "コウモリ", "komori"},
+		{"仮名の長音と拗音", "トーキョー", "tokyo"},
+		{"拗音", "しゃどう", "shado"},
+		{"促音", "キャット", "kyatto"},
+		{"促音とち", "マッチ", "matchi"},
+		{"仮名と英字の混在", "ｶｰﾄﾞ Goods", "kado goods"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Normalize(tt.in); got != tt.want {
+				t.Errorf("Normalize(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,381 @@
+package web
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"bgtools-api/db"
+	"bgtools-api/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// M_BOARDGAMEのタイトルとURLの最大文字数
+const maxTextLength int = 2048
+
+// <summary>: 管理用APIの認証を行うミドルウェアを生成します
+// <remark>: Authorizationヘッダで"Bearer <token>"を受け取り、トークンが未設定であれば全て拒否する
+func adminAuth() gin.HandlerFunc {
+	token, err := db.GetAdminToken()
+	if err != nil {
+		fmt.Printf("GetAdminToken: %v\n", err)
+	}
+
+	return func(c *gin.Context) {
+		auth := c.GetHeader("Authorization")
+
+		// Bearer形式でなければ、トークンを比較せずに拒否する
+		if token == "" || !strings.HasPrefix(auth, "Bearer ") {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, models.ErrUnauthorized)
+			return
+		}
+
+		given := strings.TrimPrefix(auth, "Bearer ")
+
+		if subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, models.ErrUnauthorized)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// <summary>: ボードゲーム情報をDBから読み込み直します
+// <remark>: 既に開かれている部屋は、作成時点のボードゲーム情報を使い続ける
+func reloadBoardgames(c *gin.Context) {
+	if err := db.ReloadCatalog(db.BgRepo); err != nil {
+		fmt.Printf("LoadBgData: %v\n", err)
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+
+	rv := models.OKMessage{
+		Message: "RELOAD.Done",
+	}
+
+	c.JSON(http.StatusOK, rv)
+}
+
+// <summary>: ボードゲームを登録します
+func setBoardgames(c *gin.Context) {
+	var req models.BoardgameDetail
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrInvalidBoardgame)
+		return
+	}
+
+	if req.Colors == nil {
+		req.Colors = []string{}
+	}
+
+	if e, ok := validateBoardgame(req); !ok {
+		c.JSON(http.StatusBadRequest, e)
+		return
+	}
+
+	if !checkExpansionBase(c, req) {
+		return
+	}
+
+	_, err := db.LoadBoardgame(db.BgRepo, req.Id)
+
+	if err == nil {
+		c.JSON(http.StatusBadRequest, models.ErrBoardgameExisted)
+		return
+
+	} else if !errors.Is(err, db.ErrNoBoardgame) {
+		fmt.Printf("LoadBoardgame: %v\n", err)
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+
+	if err := db.CreateBoardgame(db.BgRepo, req); err != nil {
+		fmt.Printf("CreateBoardgame: %v\n", err)
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+
+	refreshBoardgames()
+	c.JSON(http.StatusCreated, req)
+}
+
+// <summary>: ボードゲームの情報を更新します
+// <remark>: colorsを省略すれば、登録済みの色を変更しない
+func updateBoardgames(c *gin.Context) {
+	gameid := c.Param("gameId")
+	var req models.BoardgameDetail
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrInvalidBoardgame)
+		return
+	}
+
+	cur, ok := loadBoardgame(c, gameid)
+	if !ok {
+		return
+	}
+
+	req.Id = gameid
+
+	// 検証は更新後の色で行う
+	check := req
+	if check.Colors == nil {
+		check.Colors = cur.Colors
+	}
+
+	if e, ok := validateBoardgame(check); !ok {
+		c.JSON(http.StatusBadRequest, e)
+		return
+	}
+
+	if !checkExpansionBase(c, check) {
+		return
+	}
+
+	// 拡張が登録されているボードゲームは、拡張に変更できない
+	if check.IsExpansion && !cur.IsExpansion && !checkNoExpansions(c, gameid) {
+		return
+	}
+
+	if err := db.ModifyBoardgame(db.BgRepo, req); err != nil {
+		fmt.Printf("ModifyBoardgame: %v\n", err)
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+
+	refreshBoardgames()
+	c.JSON(http.StatusOK, check)
+}
+
+// <summary>: ボードゲームを削除します
+// <remark>: 拡張が登録されているボードゲームは、拡張を先に削除しなければ削除できない
+func deleteBoardgames(c *gin.Context) {
+	gameid := c.Param("gameId")
+
+	if _, ok := loadBoardgame(c, gameid); !ok {
+		return
+	}
+
+	if !checkNoExpansions(c, gameid) {
+		return
+	}
+
+	if err := db.RemoveBoardgame(db.BgRepo, gameid); err != nil {
+		fmt.Printf("RemoveBoardgame: %v\n", err)
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+
+	refreshBoardgames()
+
+	rv := models.OKMessage{
+		Message: "DELETE.Done",
+	}
+
+	c.JSON(http.StatusOK, rv)
+}
+
+// <summary>: ボードゲームに色を追加します
+func setColors(c *gin.Context) {
+	gameid := c.Param("gameId")
+	var req models.MstrColor
+
+	if err := c.ShouldBindJSON(&req); err != nil || !isValidColor(req.Color) {
+		c.JSON(http.StatusBadRequest, models.ErrInvalidBoardgame)
+		return
+	}
+
+	cur, ok := loadBoardgame(c, gameid)
+	if !ok {
+		return
+	}
+
+	for _, col := range cur.Colors {
+		if col == req.Color {
+			c.JSON(http.StatusBadRequest, models.ErrColorExisted)
+			return
+		}
+	}
+
+	if err := db.AddColor(db.BgRepo, gameid, req.Color); err != nil {
+		fmt.Printf("AddColor: %v\n", err)
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+
+	refreshBoardgames()
+
+	cur.Colors = append(cur.Colors, req.Color)
+	c.JSON(http.StatusCreated, cur)
+}
+
+// <summary>: ボードゲームから色を削除します
+// <remark>: 得点計算に対応するボードゲームでは、最大プレイ人数を下回る削除はできない
+func deleteColors(c *gin.Context) {
+	gameid := c.Param("gameId")
+	color := c.Param("color")
+
+	cur, ok := loadBoardgame(c, gameid)
+	if !ok {
+		return
+	}
+
+	rest := make([]string, 0, len(cur.Colors))
+
+	for _, col := range cur.Colors {
+		if col != color {
+			rest = append(rest, col)
+		}
+	}
+
+	if len(rest) == len(cur.Colors) {
+		c.JSON(http.StatusBadRequest, models.ErrColorNotOffered)
+		return
+	}
+
+	cur.Colors = rest
+
+	if e, ok := validateBoardgame(cur); !ok {
+		c.JSON(http.StatusBadRequest, e)
+		return
+	}
+
+	if err := db.RemoveColor(db.BgRepo, gameid, color); err != nil {
+		fmt.Printf("RemoveColor: %v\n", err)
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+
+	refreshBoardgames()
+	c.JSON(http.StatusOK, cur)
+}
+
+// <summary>: ボードゲームの情報を読み込みます
+// <remark>: 読み込めなければエラーを返却し、falseを返す
+func loadBoardgame(c *gin.Context, gameid string) (models.BoardgameDetail, bool) {
+	cur, err := db.LoadBoardgame(db.BgRepo, gameid)
+
+	if errors.Is(err, db.ErrNoBoardgame) {
+		c.JSON(http.StatusBadRequest, models.ErrBoardgameNotFound)
+		return cur, false
+
+	} else if err != nil {
+		fmt.Printf("LoadBoardgame: %v\n", err)
+		c.Status(http.StatusInternalServerError)
+		return cur, false
+	}
+
+	return cur, true
+}
+
+// <summary>: 拡張の基本となるボードゲームが登録されているか検証します
+// <remark>: 基本となるボードゲームがない、もしくは拡張であればエラーを返却し、falseを返す
+func checkExpansionBase(c *gin.Context, d models.BoardgameDetail) bool {
+	if !d.IsExpansion {
+		return true
+	}
+
+	base, err := db.LoadBoardgame(db.BgRepo, d.ExpansionBaseId)
+
+	if errors.Is(err, db.ErrNoBoardgame) {
+		c.JSON(http.StatusBadRequest, models.ErrInvalidExpansion)
+		return false
+
+	} else if err != nil {
+		fmt.Printf("LoadBoardgame: %v\n", err)
+		c.Status(http.StatusInternalServerError)
+		return false
+	}
+
+	// 拡張の拡張は登録できない
+	if base.IsExpansion {
+		c.JSON(http.StatusBadRequest, models.ErrInvalidExpansion)
+		return false
+	}
+
+	return true
+}
+
+// <summary>: ボードゲームに拡張が登録されていないか検証します
+// <remark>: 登録されていればエラーを返却し、falseを返す
+func checkNoExpansions(c *gin.Context, gameid string) bool {
+	has, err := db.HasExpansions(db.BgRepo, gameid)
+	if err != nil {
+		fmt.Printf("HasExpansions: %v\n", err)
+		c.Status(http.StatusInternalServerError)
+		return false
+	}
+
+	if has {
+		c.JSON(http.StatusBadRequest, models.ErrHasExpansions)
+		return false
+	}
+
+	return true
+}
+
+// <summary>: ボードゲームの情報を検証します
+// <remark>: 不正であれば返却するエラーとfalseを返す
+func validateBoardgame(d models.BoardgameDetail) (models.ErrorMessage, bool) {
+	if d.Id == "" || 8 < len(d.Id) || d.Title == "" {
+		return models.ErrInvalidBoardgame, false
+	}
+
+	if 256 < len(d.UniqueName) || 7 < len(d.PlayingTime) || d.MinAge < 0 {
+		return models.ErrInvalidBoardgame, false
+	}
+
+	// タイトルとURLはM_BOARDGAMEの列の長さ（文字数）に収める
+	if maxTextLength < utf8.RuneCountInString(d.Title) || maxTextLength < utf8.RuneCountInString(d.ProductUrl) {
+		return models.ErrInvalidBoardgame, false
+	}
+
+	// 拡張であれば、基本となるボードゲームを指定する
+	if d.IsExpansion && (d.ExpansionBaseId == "" || d.ExpansionBaseId == d.Id) {
+		return models.ErrInvalidBoardgame, false
+	}
+
+	if d.MinPlayers < 1 || 255 < d.MaxPlayers {
+		return models.ErrInvalidBoardgame, false
+	}
+
+	if d.MaxPlayers < d.MinPlayers {
+		return models.ErrInvalidPlayerRange, false
+	}
+
+	seen := make(map[string]bool, len(d.Colors))
+
+	for _, col := range d.Colors {
+		if !isValidColor(col) || seen[col] {
+			return models.ErrInvalidBoardgame, false
+		}
+
+		seen[col] = true
+	}
+
+	// 拡張は基本のボードゲームの色と組み合わせて使用するため、色の数を問わない
+	if d.ScoreTool && !d.IsExpansion && len(d.Colors) < d.MaxPlayers {
+		return models.ErrNotEnoughColors, false
+	}
+
+	return models.ErrorMessage{}, true
+}
+
+// <summary>: M_COLORに登録できる色か確認します
+func isValidColor(color string) bool {
+	return color != "" && len(color) <= 16
+}
+
+// <summary>: 更新したボードゲーム情報を読み込み直します
+// <remark>: DBへの書き込みは完了しているため、失敗してもログの出力のみとする
+func refreshBoardgames() {
+	if err := db.ReloadCatalog(db.BgRepo); err != nil {
+		fmt.Printf("LoadBgData: %v\n", err)
+	}
+}
@@ -2,11 +2,15 @@ package web
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
+	"math"
 	"net/http"
+	"strconv"
 
 	"bgtools-api/db"
 	"bgtools-api/models"
+	"bgtools-api/search"
 	"bgtools-api/ws"
 
 	"github.com/gin-gonic/gin"
@@ -14,40 +18,73 @@ import (
 	_ "github.com/go-sql-driver/mysql"
 )
 
+var (
+	// <summary>: ボードゲームの一覧で指定できる並び替えの列
+	boardgameSorts = map[string]string{
+		"id":           "`bg`.`id`",
+		"title":        "`bg`.`title`",
+		"min_players":  "`bg`.`min_players`",
+		"max_players":  "`bg`.`max_players`",
+		"min_age":      "`bg`.`min_age`",
+		"playing_time": "CAST(SUBSTRING_INDEX(`bg`.`playing_time`, '-', -1) AS UNSIGNED)",
+	}
+)
+
 // <summary>: 待ち受けるサーバのルーターを定義します
 // <remark>: httpHandlerを受け取る関数にそのまま渡せる
 func SetupRouter() *gin.Engine {
 	router := gin.Default()
+
+	conf, err := db.GetServerConfig()
+	if err != nil {
+		fmt.Printf("GetServerConfig: %v\n", err)
+	}
+
+	// 設定されたリバースプロキシ以外からのX-Forwarded-Forは信頼せず、接続元のアドレスを使用する
+	if err := router.SetTrustedProxies(conf.TrustedProxies); err != nil {
+		fmt.Printf("SetTrustedProxies: %v\n", err)
+		router.SetTrustedProxies(nil)
+	}
 	v1 := router.Group("v1")
 
-	//v1.GET("/boardgames", getBoardgames)
-	//v1.GET("/boardgames/:gameId", getBoardgames)
+	v1.GET("/boardgames", getBoardgames)
+	v1.GET("/boardgames/:gameId", getBoardgames)
+	v1.GET("/search/boardgames", searchBoardgames)
 
 	score := v1.Group("score")
 
 	score.GET("/entry", wsEntry)
 	score.GET("/rooms/:roomId", checkRoom)
+	score.GET("/rooms/:roomId/history", getHistory)
 	score.GET("/boardgames", getScoreSupported)
 	score.GET("/boardgames/:gameId", getScoreSupported)
+	score.GET("/plays", getPlays)
+	score.GET("/plays/:playId", getPlay)
 
 	stat := score.Group("statistics")
 
 	stat.GET("/rooms", getRooms)
 	stat.GET("/rooms/:roomId", getRooms)
 	stat.GET("/connections", getConnections)
-	stat.GET("/connections/:connId", getConnections)
+	stat.GET("/connections/:playerId", getConnections)
+	stat.GET("/heartbeat", getHeartbeat)
 
-	//admin := v1.Group("admin")
+	admin := v1.Group("admin")
+	admin.Use(adminAuth())
 
-	//admin.POST("/boardgames", setBoardgames)
-	//admin.PUT("/boardgames/:gameId", updateBoardgames)
+	admin.POST("/reload", reloadBoardgames)
+	admin.POST("/boardgames", setBoardgames)
+	admin.PUT("/boardgames/:gameId", updateBoardgames)
+	admin.DELETE("/boardgames/:gameId", deleteBoardgames)
+	admin.POST("/boardgames/:gameId/colors", setColors)
+	admin.DELETE("/boardgames/:gameId/colors/:color", deleteColors)
 
 	r, err := initDB()
 	if err != nil {
 		fmt.Printf("initDB: %v\n", err)
 	}
 
-	if err := db.LoadBgDataForScore(r); err != nil {
+	if err := db.ReloadCatalog(r); err != nil {
 		fmt.Printf("LoadBgData: %v\n", err)
 	}
 
@@ -68,26 +105,166 @@ func checkRoom(c *gin.Context) {
 	info, exist := ws.RoomPool.Get(roomid)
 
 	rv := models.CheckRoomResult{
-		IsExist: exist,
-		GameId:  "",
+		IsExist:  exist,
+		IsLocked: false,
+		GameId:   "",
 	}
 
 	if exist {
+		rv.IsLocked = info.IsLocked
 		rv.GameId = info.GameId
 	}
 
 	c.JSON(http.StatusOK, rv)
 }
 
+// <summary>: 部屋の得点と履歴を取得します
+func getHistory(c *gin.Context) {
+	roomid := c.Param("roomId")
+
+	room, exist := ws.RoomPool.Get(roomid)
+
+	if !exist {
+		c.JSON(http.StatusBadRequest, models.ErrRoomNotFound)
+		return
+	}
+
+	// 合言葉が必要な部屋では、ヘッダで合言葉を受け取る
+	if !checkPassphrase(c, roomid, room) {
+		return
+	}
+
+	rv := models.HistoryResponse{
+		RoomId:  roomid,
+		GameId:  room.GameId,
+		Players: room.Players,
+		History: room.History,
+	}
+
+	c.JSON(http.StatusOK, rv)
+}
+
+// <summary>: ボードゲームの一覧、もしくは指定されたボードゲームの情報を取得します
+// <remark>: 一覧はプレイ人数、年齢、プレイ時間、拡張か否かで絞り込み、並び替えとページングができる
+// <remark>: 指定されたボードゲームの情報には、拡張を入れ子にして返却する
+func getBoardgames(c *gin.Context) {
+	gameid := c.Param("gameId")
+
+	if gameid != "" {
+		detail, err := db.LoadBoardgameTree(db.BgRepo, gameid)
+
+		if errors.Is(err, db.ErrNoBoardgame) {
+			c.JSON(http.StatusBadRequest, models.ErrBoardgameNotFound)
+			return
+
+		} else if err != nil {
+			fmt.Printf("LoadBoardgameTree: %v\n", err)
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		c.JSON(http.StatusOK, detail)
+		return
+	}
+
+	players, ok1 := queryInt(c, "players", 0, 1, 255)
+	age, ok2 := queryInt(c, "age", 0, 0, 255)
+	maxTime, ok3 := queryInt(c, "max_time", 0, 1, math.MaxInt32)
+	limit, ok4 := queryInt(c, "limit", 20, 1, 100)
+	offset, ok5 := queryInt(c, "offset", 0, 0, math.MaxInt32)
+	sort, ok6 := boardgameSorts[c.DefaultQuery("sort", "title")]
+
+	if !ok1 || !ok2 || !ok3 || !ok4 || !ok5 || !ok6 {
+		c.JSON(http.StatusBadRequest, models.ErrInvalidQuery)
+		return
+	}
+
+	q := models.BoardgameQuery{
+		Players: players,
+		Age:     age,
+		MaxTime: maxTime,
+		BaseId:  c.Query("base_id"),
+		Sort:    sort,
+		Limit:   limit,
+		Offset:  offset,
+	}
+
+	switch c.Query("order") {
+	case "", "asc":
+		q.Desc = false
+
+	case "desc":
+		q.Desc = true
+
+	default:
+		c.JSON(http.StatusBadRequest, models.ErrInvalidQuery)
+		return
+	}
+
+	// expansionが指定されていれば、拡張か基本のボードゲームのみに絞り込む
+	if v := c.Query("expansion"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrInvalidQuery)
+			return
+		}
+
+		q.IsExpansion = &b
+	}
+
+	// nestが指定されていれば、基本のボードゲームのみを一覧にして拡張を入れ子にする
+	if v := c.Query("nest"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil || (b && q.IsExpansion != nil && *q.IsExpansion) {
+			c.JSON(http.StatusBadRequest, models.ErrInvalidQuery)
+			return
+		}
+
+		if b {
+			base := false
+			q.IsExpansion = &base
+			q.Nest = true
+		}
+	}
+
+	list, err := db.LoadBoardgames(db.BgRepo, q)
+	if err != nil {
+		fmt.Printf("LoadBoardgames: %v\n", err)
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusOK, list)
+}
+
+// <summary>: タイトルとユニーク名からボードゲームを検索します
+// <remark>: DBには問い合わせず、読み込み済みの検索用索引を使用する
+func searchBoardgames(c *gin.Context) {
+	query := c.Query("q")
+	limit, ok := queryInt(c, "limit", 10, 1, 50)
+
+	if !ok || search.Normalize(query) == "" {
+		c.JSON(http.StatusBadRequest, models.ErrInvalidQuery)
+		return
+	}
+
+	rv := models.SearchResult{
+		Query: query,
+		Hits:  search.Current().Search(query, limit),
+	}
+
+	c.JSON(http.StatusOK, rv)
+}
+
 // <summary>: ボードゲーム情報を取得します
 func getScoreSupported(c *gin.Context) {
 	gameid := c.Param("gameId")
 
 	if gameid == "" {
-		c.JSON(http.StatusOK, models.BgScore)
+		c.JSON(http.StatusOK, models.BgScore())
 
 	} else {
-		data, ok := models.BgScore[gameid]
+		data, ok := models.BgScore()[gameid]
 
 		if ok {
 			res := make(map[string]models.BgPartialData, 1)
@@ -101,19 +278,86 @@ func getScoreSupported(c *gin.Context) {
 	}
 }
 
+// <summary>: プレイ記録の一覧を取得します
+// <remark>: game_idで絞り込み、limitとoffsetでページングできる
+func getPlays(c *gin.Context) {
+	limit, ok1 := queryInt(c, "limit", 20, 1, 100)
+	offset, ok2 := queryInt(c, "offset", 0, 0, math.MaxInt32)
+
+	if !ok1 || !ok2 {
+		c.JSON(http.StatusBadRequest, models.ErrInvalidQuery)
+		return
+	}
+
+	q := models.PlayQuery{
+		GameId: c.Query("game_id"),
+		Limit:  limit,
+		Offset: offset,
+	}
+
+	plays, err := db.LoadPlays(db.BgRepo, q)
+	if err != nil {
+		fmt.Printf("LoadPlays: %v\n", err)
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusOK, plays)
+}
+
+// <summary>: プレイ記録を取得します
+func getPlay(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("playId"), 10, 64)
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrPlayNotFound)
+		return
+	}
+
+	play, err := db.LoadPlay(db.BgRepo, id)
+
+	if errors.Is(err, db.ErrNoPlay) {
+		c.JSON(http.StatusBadRequest, models.ErrPlayNotFound)
+		return
+
+	} else if err != nil {
+		fmt.Printf("LoadPlay: %v\n", err)
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusOK, play)
+}
+
 // <summary>: 部屋情報を取得します
+// <remark>: 合言葉が必要な部屋のプレイヤーと得点は、合言葉を受け取った場合のみ返却する
 func getRooms(c *gin.Context) {
 	roomid := c.Param("roomId")
 	summary := make([]models.RoomSummary, 0, ws.RoomPool.Count())
 
 	pack := func(id string, room models.RoomInfoSet) {
+		// 非公開の部屋は一覧に表示しない
+		if roomid == "" && room.IsPrivate {
+			return
+		}
+
 		gameid := room.GameId
 
 		rs := models.RoomSummary{
-			RoomId:   id,
-			GameId:   gameid,
-			GameData: models.BgScore[gameid],
-			Players:  room.Players,
+			RoomId:       id,
+			GameId:       gameid,
+			Expansions:   room.Expansions,
+			GameData:     room.GameData,
+			Players:      room.Players,
+			Spectators:   room.Spectators,
+			CreatedAt:    room.CreatedAt,
+			LastActivity: room.LastActivity,
+		}
+
+		// 合言葉が必要な部屋は、一覧にプレイヤーと得点を表示しない
+		if roomid == "" && room.IsLocked {
+			rs.Players = []models.PlayerInfoSet{}
+			rs.Spectators = []models.SpectatorInfoSet{}
 		}
 
 		summary = append(summary, rs)
@@ -126,6 +370,11 @@ func getRooms(c *gin.Context) {
 		room, exist := ws.RoomPool.Get(roomid)
 
 		if exist {
+			// 合言葉が必要な部屋では、ヘッダで合言葉を受け取る
+			if !checkPassphrase(c, roomid, room) {
+				return
+			}
+
 			pack(roomid, room)
 
 		} else {
@@ -138,13 +387,14 @@ func getRooms(c *gin.Context) {
 }
 
 // <summary>: 接続情報を取得します
+// <remark>: 非公開、もしくは合言葉が必要な部屋にいる接続は、入室していないものとして返却する
 func getConnections(c *gin.Context) {
-	connid := c.Param("connId")
+	playerid := c.Param("playerId")
 	summary := make([]models.ConnectionSummary, 0, ws.PlayerPool.Count())
 
 	empty := func(id string) models.ConnectionSummary {
 		return models.ConnectionSummary{
-			ConnId:       id,
+			PlayerId:     id,
 			RoomId:       "",
 			GameId:       "",
 			PlayerColor:  "",
@@ -154,43 +404,47 @@ func getConnections(c *gin.Context) {
 				MinPlayers: 0,
 				MaxPlayers: 0,
 				Colors:     []string{},
+				Categories: []models.ScoreCategory{},
 			},
 		}
 	}
 
-	if connid == "" {
+	if playerid == "" {
 		data := ws.PlayerPool.PlayerRoomData()
 
-		for cid, roomid := range data {
+		for pid, roomid := range data {
 			if roomid == "" {
-				summary = append(summary, empty(cid))
+				summary = append(summary, empty(pid))
 				continue
 			}
 
 			room, ok := ws.RoomPool.Get(roomid)
 
-			if !ok {
-				summary = append(summary, empty(cid))
+			// 非公開、もしくは合言葉が必要な部屋は、入室先を明かさない
+			if !ok || isHiddenRoom(room) {
+				summary = append(summary, empty(pid))
 				continue
 			}
 
-			pcol := ""
+			var self models.PlayerInfoSet
 			other := make([]models.PlayerInfoSet, 0, len(room.Players))
 
 			for _, player := range room.Players {
-				if player.ConnId != cid {
+				if player.PlayerId != pid {
 					other = append(other, player)
 				} else {
-					pcol = player.PlayerColor
+					self = player
 				}
 			}
 
 			cs := models.ConnectionSummary{
-				ConnId:       cid,
+				PlayerId:     pid,
+				Name:         self.Name,
+				Avatar:       self.Avatar,
 				RoomId:       roomid,
 				GameId:       room.GameId,
-				GameData:     models.BgScore[room.GameId],
-				PlayerColor:  pcol,
+				GameData:     room.GameData,
+				PlayerColor:  self.PlayerColor,
 				OtherPlayers: other,
 			}
 
@@ -200,7 +454,7 @@ func getConnections(c *gin.Context) {
 		c.JSON(http.StatusOK, summary)
 
 	} else {
-		player, ok := ws.PlayerPool.Get(connid)
+		_, player, ok := ws.PlayerPool.GetByPlayerId(playerid)
 
 		if !ok {
 			c.JSON(http.StatusBadRequest, models.ErrConnectionNotFound)
@@ -209,16 +463,17 @@ func getConnections(c *gin.Context) {
 
 		if player.RoomId == "" {
 			c.JSON(http.StatusOK, []models.ConnectionSummary{
-				empty(connid),
+				empty(playerid),
 			})
 			return
 		}
 
 		room, ok := ws.RoomPool.Get(player.RoomId)
 
-		if !ok {
+		// 非公開、もしくは合言葉が必要な部屋は、入室先を明かさない
+		if !ok || isHiddenRoom(room) {
 			c.JSON(http.StatusOK, []models.ConnectionSummary{
-				empty(connid),
+				empty(playerid),
 			})
 			return
 		}
@@ -227,12 +482,14 @@ func getConnections(c *gin.Context) {
 		other := make([]models.PlayerInfoSet, 0, len(room.Players))
 
 		for _, p := range room.Players {
-			if p.ConnId == connid {
+			if p.PlayerId == playerid {
 				cs = models.ConnectionSummary{
-					ConnId:      p.ConnId,
+					PlayerId:    p.PlayerId,
+					Name:        p.Name,
+					Avatar:      p.Avatar,
 					RoomId:      player.RoomId,
 					GameId:      room.GameId,
-					GameData:    models.BgScore[room.GameId],
+					GameData:    room.GameData,
 					PlayerColor: p.PlayerColor,
 				}
 
@@ -248,6 +505,60 @@ func getConnections(c *gin.Context) {
 	}
 }
 
+// <summary>: 接続の死活監視の状況を取得します
+func getHeartbeat(c *gin.Context) {
+	rv := models.HeartbeatSummary{
+		Connections:    ws.PlayerPool.Count(),
+		Reaped:         ws.ReapedCount(),
+		IdleTimeoutSec: int(ws.IdleTimeout().Seconds()),
+	}
+
+	c.JSON(http.StatusOK, rv)
+}
+
+// <summary>: 接続情報に入室先を表示しない部屋か確認します
+func isHiddenRoom(room models.RoomInfoSet) bool {
+	return room.IsPrivate || room.IsLocked
+}
+
+// <summary>: ヘッダで受け取った合言葉を、接続元と部屋の組毎に失敗回数を制限しながら検証します
+// <remark>: 一致しなければエラーを返却し、falseを返す
+func checkPassphrase(c *gin.Context, roomid string, room models.RoomInfoSet) bool {
+	errMsg, ok := ws.CheckPassphrase(c.ClientIP(), roomid, room, c.GetHeader("X-Room-Passphrase"))
+	if ok {
+		return true
+	}
+
+	switch errMsg {
+	case models.ErrPassphraseRequired:
+		c.JSON(http.StatusUnauthorized, errMsg)
+
+	case models.ErrTooManyAttempts:
+		c.JSON(http.StatusTooManyRequests, errMsg)
+
+	default:
+		c.JSON(http.StatusForbidden, errMsg)
+	}
+
+	return false
+}
+
+// <summary>: クエリパラメータを整数として取得します
+// <remark>: 指定がなければdefを返し、整数でないか範囲外であればfalseを返す
+func queryInt(c *gin.Context, key string, def, min, max int) (int, bool) {
+	v := c.Query(key)
+	if v == "" {
+		return def, true
+	}
+
+	n, err := strconv.Atoi(v)
+	if err != nil || n < min || max < n {
+		return 0, false
+	}
+
+	return n, true
+}
+
 // <summary>: DBとの接続についての初期処理
 func initDB() (*db.BgRepository, error) {
 	driver, dsn, err := db.GetDataSourceName()
@@ -1,12 +1,21 @@
 package ws
 
 import (
+	"context"
 	"fmt"
+	"time"
 
+	"bgtools-api/db"
 	"bgtools-api/models"
 )
 
+const (
+	// プレイ記録の保存を待つ時間
+	savePlayTimeout time.Duration = 10 * time.Second
+)
+
 // <summary>: [Method] CREATE に関する動作を定義します
+// <remark>: 部屋の生成はロビー（ServeRequest）でのみ行い、room_idが空であればサーバで払い出す
 func actionCreate(req models.WsRequest) {
 	logp := newLogParams(req.ConnId)
 	logp.Prefix = fmt.Sprintf("<%s>", models.CREATE.String())
@@ -25,49 +34,122 @@ func actionCreate(req models.WsRequest) {
 		return
 	}
 
-	// リクエストされた部屋情報がなければエラー
-	if _, exist := RoomPool.Get(req.RoomId); exist {
+	// 指定された部屋IDに使用できない文字や長さが含まれていればエラー
+	if req.RoomId != "" && !isValidRoomId(req.RoomId) {
+		pc.sendError(models.ErrInvalidRoomId, logp)
+		return
+	}
+
+	// リクエストされた部屋情報が既にあればエラー
+	if rooms.exists(req.RoomId) {
 		pc.sendError(models.ErrRoomExisted, logp)
 		return
 	}
 
-	data, exist := models.BgScore[req.GameId]
+	data, errMsg, ok := combineGameData(models.BgScore(), req.GameId, req.Expansions)
+
+	// ボードゲーム情報がない、もしくは拡張の指定が不正であればエラー
+	if !ok {
+		pc.sendError(errMsg, logp)
+		return
+	}
+
+	// ボードゲームで使用できない色であればエラー
+	if !isColorOffered(data.Colors, req.PlayerColor) {
+		pc.sendError(models.ErrColorNotOffered, logp)
+		return
+	}
+
+	name, nameOk := normalizeName(req.Name)
+	avatar, avatarOk := normalizeAvatar(req.Avatar)
 
-	// リクエストされたボードゲーム情報がなければエラー
-	if !exist {
-		pc.sendError(models.ErrBoardgameNotFound, logp)
+	// 表示名、もしくはアバターが不正であればエラー
+	if !nameOk || !avatarOk {
+		pc.sendError(models.ErrInvalidName, logp)
+		return
+	}
+
+	generated := req.RoomId == ""
+
+	if generated {
+		code, err := newRoomCode()
+
+		// 部屋IDを払い出せなければエラー
+		if err != nil {
+			logp.log(err.Error())
+			pc.sendError(models.ErrRoomIdUnavailable, logp)
+			return
+		}
+
+		req.RoomId = code
+	}
+
+	// 他の部屋への入室と競合していればエラー
+	if !claimRoom(req.ConnId, req.RoomId) {
+		if generated {
+			roomCodes.release(req.RoomId)
+		}
+
+		pc.sendError(models.ErrEnteredAnotherRoom, logp)
 		return
 	}
 
 	players := make([]models.PlayerInfoSet, 1, data.MaxPlayers)
 	players[0] = models.PlayerInfoSet{
 		ConnId:      req.ConnId,
+		PlayerId:    pc.PlayerId,
+		Name:        name,
+		Avatar:      avatar,
 		PlayerColor: req.PlayerColor,
+		IsConnected: true,
+		Points:      []int{},
+	}
+
+	now := time.Now()
+
+	expansions := req.Expansions
+	if expansions == nil {
+		expansions = []string{}
 	}
 
 	room := models.RoomInfoSet{
-		GameId:  req.GameId,
-		Players: players,
+		GameId:       req.GameId,
+		Expansions:   expansions,
+		HostId:       pc.PlayerId,
+		IsPrivate:    req.IsPrivate,
+		IsLocked:     req.PassHash != "",
+		Players:      players,
+		Spectators:   []models.SpectatorInfoSet{},
+		CreatedAt:    now,
+		LastActivity: now,
+		GameData:     data,
+		PassHash:     req.PassHash,
 	}
 
-	RoomPool.Set(req.RoomId, room)
-	PlayerPool.SetRoomId(req.ConnId, req.RoomId)
+	res, started := rooms.start(req.RoomId, room)
+
+	if !started {
+		PlayerPool.SetRoomId(req.ConnId, "")
+
+		if generated {
+			roomCodes.release(req.RoomId)
+		}
+
+		pc.sendError(models.ErrRoomExisted, logp)
+		return
+	}
 
 	logp.Method = models.OK
 	response := models.WsResponse{
 		Method: models.OK.String(),
-		Params: models.RoomResponse{
-			IsWait:   1 < data.MinPlayers,
-			RoomId:   req.RoomId,
-			RoomInfo: room,
-		},
+		Params: res,
 	}
 
 	pc.sendJson(response, logp)
 }
 
 // <summary>: [Method] JOIN に関する動作を定義します
-func actionJoin(req models.WsRequest) {
+func actionJoin(a *roomActor, req models.WsRequest) {
 	logp := newLogParams(req.ConnId)
 	logp.Prefix = fmt.Sprintf("<%s>", models.JOIN.String())
 
@@ -85,23 +167,47 @@ func actionJoin(req models.WsRequest) {
 		return
 	}
 
-	room, exist := RoomPool.Get(req.RoomId)
+	// 合言葉が必要な部屋で合言葉がなければエラー
+	if a.room.IsLocked && req.Passphrase == "" {
+		pc.sendError(models.ErrPassphraseRequired, logp)
+		return
+	}
 
-	// リクエストされた部屋情報がなければエラー
-	if !exist {
-		pc.sendError(models.ErrRoomNotFound, logp)
+	// 受信時に検証した合言葉が、部屋の合言葉と異なればエラー
+	if a.room.PassHash != "" && req.VerifiedHash != a.room.PassHash {
+		pc.sendError(models.ErrWrongPassphrase, logp)
 		return
 	}
 
 	// リクエストされた部屋情報とゲームが不一致であればエラー
-	if room.GameId != req.GameId {
+	if a.room.GameId != req.GameId {
+		pc.sendError(models.ErrMismatchGame, logp)
+		return
+	}
+
+	// 拡張が指定されていれば、部屋で使用している拡張と一致しなければエラー
+	if req.Expansions != nil && !isSameExpansions(a.room.Expansions, req.Expansions) {
 		pc.sendError(models.ErrMismatchGame, logp)
 		return
 	}
 
+	data := a.room.GameData
+
+	// 部屋が既に満員であればエラー
+	if len(a.room.Players) >= data.MaxPlayers {
+		pc.sendError(models.ErrRoomFull, logp)
+		return
+	}
+
+	// ボードゲームで使用できない色であればエラー
+	if !isColorOffered(data.Colors, req.PlayerColor) {
+		pc.sendError(models.ErrColorNotOffered, logp)
+		return
+	}
+
 	col_ex := false
 
-	for _, p := range room.Players {
+	for _, p := range a.room.Players {
 		if p.PlayerColor == req.PlayerColor {
 			col_ex = true
 			break
@@ -114,49 +220,111 @@ func actionJoin(req models.WsRequest) {
 		return
 	}
 
+	name, nameOk := normalizeName(req.Name)
+	avatar, avatarOk := normalizeAvatar(req.Avatar)
+
+	// 表示名、もしくはアバターが不正であればエラー
+	if !nameOk || !avatarOk {
+		pc.sendError(models.ErrInvalidName, logp)
+		return
+	}
+
+	// 同じ表示名を使おうとしていればエラー
+	if a.isNameTaken(name, "") {
+		pc.sendError(models.ErrNameTaken, logp)
+		return
+	}
+
+	// 他の部屋への入室と競合していればエラー
+	if !claimRoom(req.ConnId, a.id) {
+		pc.sendError(models.ErrEnteredAnotherRoom, logp)
+		return
+	}
+
 	player := models.PlayerInfoSet{
 		ConnId:      req.ConnId,
+		PlayerId:    pc.PlayerId,
+		Name:        name,
+		Avatar:      avatar,
 		PlayerColor: req.PlayerColor,
+		IsConnected: true,
+		Points:      []int{},
 	}
-	room.Players = append(room.Players, player)
+	a.room.Players = append(a.room.Players, player)
+	a.calculate()
 
-	min := models.BgScore[room.GameId].MinPlayers
+	res := a.response()
+	res.History = res.RoomInfo.History
 
 	logp.Method = models.OK
 	response := models.WsResponse{
 		Method: models.OK.String(),
-		Params: models.RoomResponse{
-			IsWait:   len(room.Players) < min,
-			RoomId:   req.RoomId,
-			RoomInfo: room,
-		},
+		Params: res,
 	}
 
-	RoomPool.Set(req.RoomId, room)
-	PlayerPool.SetRoomId(req.ConnId, req.RoomId)
 	pc.sendJson(response, logp)
+	a.notify(req.ConnId)
+}
 
-	for _, p := range room.Players {
-		if p.ConnId == req.ConnId {
-			continue
-		}
+// <summary>: [Method] WATCH に関する動作を定義します
+// <remark>: 観戦者は席と色を持たず、部屋の人数にも数えない
+func actionWatch(a *roomActor, req models.WsRequest) {
+	logp := newLogParams(req.ConnId)
+	logp.Prefix = fmt.Sprintf("<%s>", models.WATCH.String())
 
-		inpc, ex := PlayerPool.Get(p.ConnId)
-		if !ex {
-			continue
-		}
+	pc, ok := PlayerPool.Get(req.ConnId)
+	if !ok {
+		logp.IsProcError = true
+		logp.log("送信されたconnection_idが不正です")
+
+		return
+	}
+
+	// 別室に既に入室していればエラー
+	if pc.RoomId != "" {
+		pc.sendError(models.ErrEnteredAnotherRoom, logp)
+		return
+	}
+
+	// 合言葉が必要な部屋で合言葉がなければエラー
+	if a.room.IsLocked && req.Passphrase == "" {
+		pc.sendError(models.ErrPassphraseRequired, logp)
+		return
+	}
+
+	// 受信時に検証した合言葉が、部屋の合言葉と異なればエラー
+	if a.room.PassHash != "" && req.VerifiedHash != a.room.PassHash {
+		pc.sendError(models.ErrWrongPassphrase, logp)
+		return
+	}
+
+	// 他の部屋への入室と競合していればエラー
+	if !claimRoom(req.ConnId, a.id) {
+		pc.sendError(models.ErrEnteredAnotherRoom, logp)
+		return
+	}
+
+	spectator := models.SpectatorInfoSet{
+		ConnId:   req.ConnId,
+		PlayerId: pc.PlayerId,
+	}
+	a.room.Spectators = append(a.room.Spectators, spectator)
 
-		l := newLogParams(p.ConnId)
-		l.Method = models.NOTIFY
-		l.Prefix = fmt.Sprintf("<%s>", models.NOTIFY.String())
-		response.Method = models.NOTIFY.String()
+	res := a.response()
+	res.History = res.RoomInfo.History
 
-		inpc.sendJson(response, l)
+	logp.Method = models.OK
+	response := models.WsResponse{
+		Method: models.OK.String(),
+		Params: res,
 	}
+
+	pc.sendJson(response, logp)
+	a.notify(req.ConnId)
 }
 
 // <summary>: [Method] LEAVE に関する動作を定義します
-func actionLeave(req models.WsRequest) {
+func actionLeave(a *roomActor, req models.WsRequest) {
 	logp := newLogParams(req.ConnId)
 	logp.Prefix = fmt.Sprintf("<%s>", models.LEAVE.String())
 
@@ -168,14 +336,12 @@ func actionLeave(req models.WsRequest) {
 		return
 	}
 
-	// リクエストされた部屋情報がなければエラー
-	if _, exist := RoomPool.Get(req.RoomId); !exist {
-		pc.sendError(models.ErrRoomNotFound, logp)
+	// 部屋にプレイヤー、観戦者ともに入室していなければエラー
+	if !a.removePlayer(req.ConnId) && !a.removeSpectator(req.ConnId) {
+		pc.sendError(models.ErrNotInRoom, logp)
 		return
 	}
 
-	notify := deletePlayerInfo(req.ConnId)
-
 	logp.Method = models.OK
 	response := models.WsResponse{
 		Method: models.OK.String(),
@@ -185,17 +351,69 @@ func actionLeave(req models.WsRequest) {
 	}
 
 	pc.sendJson(response, logp)
+	a.notify("")
+}
+
+// <summary>: [Method] EJECT に関する動作を定義します
+// <remark>: 部屋のホストのみが実行できる
+func actionEject(a *roomActor, req models.WsRequest) {
+	logp := newLogParams(req.ConnId)
+	logp.Prefix = fmt.Sprintf("<%s>", models.EJECT.String())
 
-	// notifyが空文字でなければブロードキャスト
-	if notify != "" {
-		notifyOtherPlayers(notify)
+	pc, ok := PlayerPool.Get(req.ConnId)
+	if !ok {
+		logp.IsProcError = true
+		logp.log("送信されたconnection_idが不正です")
+
+		return
+	}
+
+	// 部屋にプレイヤーが入室していなければエラー
+	if a.indexOf(req.ConnId) < 0 {
+		pc.sendError(models.ErrNotInRoom, logp)
+		return
 	}
+
+	// 部屋のホストでなければエラー
+	if a.room.HostId != pc.PlayerId {
+		pc.sendError(models.ErrNotHost, logp)
+		return
+	}
+
+	// ホスト自身を対象にしていればエラー
+	if req.TargetId == pc.PlayerId {
+		pc.sendError(models.ErrEjectSelf, logp)
+		return
+	}
+
+	i := a.indexOfPlayer(req.TargetId)
+
+	// 対象のプレイヤーが部屋にいなければエラー
+	if i < 0 {
+		pc.sendError(models.ErrPlayerNotFound, logp)
+		return
+	}
+
+	target := a.room.Players[i].ConnId
+	a.eject(target, "host")
+
+	logp.Method = models.OK
+	response := models.WsResponse{
+		Method: models.OK.String(),
+		Params: models.OKMessage{
+			Message: "EJECT.Done",
+		},
+	}
+
+	pc.sendJson(response, logp)
+	a.notify("")
 }
 
-// <summary>: [Method] BROADCAST に関する動作を定義します
-func actionBroadcast(req models.WsRequest) {
+// <summary>: [Method] RENAME に関する動作を定義します
+// <remark>: 表示名とアバターの両方をリクエストの内容で置き換える
+func actionRename(a *roomActor, req models.WsRequest) {
 	logp := newLogParams(req.ConnId)
-	logp.Prefix = fmt.Sprintf("<%s>", models.BROADCAST.String())
+	logp.Prefix = fmt.Sprintf("<%s>", models.RENAME.String())
 
 	pc, ok := PlayerPool.Get(req.ConnId)
 	if !ok {
@@ -205,41 +423,395 @@ func actionBroadcast(req models.WsRequest) {
 		return
 	}
 
-	room, exist := RoomPool.Get(req.RoomId)
+	i := a.indexOf(req.ConnId)
+
+	// 部屋にプレイヤーが入室していなければエラー
+	if i < 0 {
+		pc.sendError(models.ErrNotInRoom, logp)
+		return
+	}
+
+	name, nameOk := normalizeName(req.Name)
+	avatar, avatarOk := normalizeAvatar(req.Avatar)
+
+	// 表示名、もしくはアバターが不正であればエラー
+	if !nameOk || !avatarOk {
+		pc.sendError(models.ErrInvalidName, logp)
+		return
+	}
+
+	// 他のプレイヤーと同じ表示名を使おうとしていればエラー
+	if a.isNameTaken(name, req.ConnId) {
+		pc.sendError(models.ErrNameTaken, logp)
+		return
+	}
+
+	a.room.Players[i].Name = name
+	a.room.Players[i].Avatar = avatar
+
+	logp.Method = models.OK
+	response := models.WsResponse{
+		Method: models.OK.String(),
+		Params: a.response(),
+	}
+
+	pc.sendJson(response, logp)
+	a.notify(req.ConnId)
+}
+
+// <summary>: [Method] RECOLOR に関する動作を定義します
+// <remark>: 空いている色であれば即座に変更し、他のプレイヤーの色であれば相手の同意を得て交換する
+func actionRecolor(a *roomActor, req models.WsRequest) {
+	logp := newLogParams(req.ConnId)
+	logp.Prefix = fmt.Sprintf("<%s>", models.RECOLOR.String())
+
+	pc, ok := PlayerPool.Get(req.ConnId)
+	if !ok {
+		logp.IsProcError = true
+		logp.log("送信されたconnection_idが不正です")
 
-	// リクエストされた部屋情報がなければエラー
-	if !exist {
-		pc.sendError(models.ErrRoomNotFound, logp)
 		return
 	}
 
 	// リクエストされた部屋情報とゲームが不一致であればエラー
-	if room.GameId != req.GameId {
+	if a.room.GameId != req.GameId {
 		pc.sendError(models.ErrMismatchGame, logp)
 		return
 	}
 
-	ex_conn := false
+	i := a.indexOf(req.ConnId)
 
-	for _, p := range room.Players {
-		if p.ConnId == req.ConnId {
-			ex_conn = true
+	// 部屋にプレイヤーが入室していなければエラー
+	if i < 0 {
+		pc.sendError(models.ErrNotInRoom, logp)
+		return
+	}
+
+	// ボードゲームで使用できない色であればエラー
+	if !isColorOffered(a.room.GameData.Colors, req.PlayerColor) {
+		pc.sendError(models.ErrColorNotOffered, logp)
+		return
+	}
+
+	self := a.room.Players[i]
+	j := -1
+
+	for k, p := range a.room.Players {
+		if k != i && p.PlayerColor == req.PlayerColor {
+			j = k
 			break
 		}
 	}
 
+	switch {
+	case j < 0:
+		// 空いている色であれば、そのまま変更する
+		a.room.Players[i].PlayerColor = req.PlayerColor
+
+	case a.isOffered(a.room.Players[j], self):
+		// 相手からの申し込みに応じていれば、色を交換する
+		delete(a.offers, a.room.Players[j].PlayerId)
+
+		a.room.Players[j].PlayerColor = self.PlayerColor
+		a.room.Players[i].PlayerColor = req.PlayerColor
+
+	default:
+		// 他のプレイヤーの色であれば、交換を申し込む
+		a.offers[self.PlayerId] = colorOffer{
+			targetId:  a.room.Players[j].PlayerId,
+			fromColor: self.PlayerColor,
+			toColor:   req.PlayerColor,
+		}
+
+		a.offerRecolor(a.room.Players[j].ConnId, self)
+
+		logp.Method = models.OK
+		response := models.WsResponse{
+			Method: models.OK.String(),
+			Params: models.OKMessage{
+				Message: "RECOLOR.Offered",
+			},
+		}
+
+		pc.sendJson(response, logp)
+		return
+	}
+
+	// 自分からの申し込みは、色が変わったため無効とする
+	delete(a.offers, self.PlayerId)
+
+	logp.Method = models.OK
+	response := models.WsResponse{
+		Method: models.OK.String(),
+		Params: a.response(),
+	}
+
+	pc.sendJson(response, logp)
+	a.notify(req.ConnId)
+}
+
+// <summary>: [Method] FINISH に関する動作を定義します
+// <remark>: 部屋のホストのみが実行でき、プレイ記録を保存して部屋を閉じる
+func actionFinish(a *roomActor, req models.WsRequest) {
+	logp := newLogParams(req.ConnId)
+	logp.Prefix = fmt.Sprintf("<%s>", models.FINISH.String())
+
+	pc, ok := PlayerPool.Get(req.ConnId)
+	if !ok {
+		logp.IsProcError = true
+		logp.log("送信されたconnection_idが不正です")
+
+		return
+	}
+
 	// 部屋にプレイヤーが入室していなければエラー
-	if !ex_conn {
+	if a.indexOf(req.ConnId) < 0 {
 		pc.sendError(models.ErrNotInRoom, logp)
 		return
 	}
 
+	// 部屋のホストでなければエラー
+	if a.room.HostId != pc.PlayerId {
+		pc.sendError(models.ErrNotHost, logp)
+		return
+	}
+
+	// プレイ記録の保存中であればエラー
+	if a.finishing {
+		pc.sendError(models.ErrFinishing, logp)
+		return
+	}
+
+	// 保存の完了までは得点の変更を受け付けない
+	a.finishing = true
+
+	go savePlay(a.id, req, a.record())
+}
+
+// <summary>: プレイ記録を保存し、結果を部屋のアクターへ返します
+// <remark>: DBへの書き込みで部屋のアクターを止めないよう、アクターの外で期限付きで行う
+func savePlay(roomid string, req models.WsRequest, rec models.PlayRecord) {
+	ctx, cancel := context.WithTimeout(context.Background(), savePlayTimeout)
+	defer cancel()
+
+	saved, err := db.SavePlay(ctx, db.BgRepo, rec)
+
+	done := func(a *roomActor, req models.WsRequest) {
+		a.completeFinish(req, saved, err)
+	}
+
+	// 保存中に部屋が閉じられていれば、結果を返す先はない
+	if !rooms.dispatch(roomid, req, done) {
+		logp := newLogParams(req.ConnId)
+		logp.Prefix = fmt.Sprintf("<%s>", models.FINISH.String())
+		logp.log(fmt.Sprintf("プレイ記録の保存中に部屋が閉じられました: %s", roomid))
+	}
+}
+
+// <summary>: プレイ記録の保存結果を通知し、保存できていれば部屋を閉じます
+func (a *roomActor) completeFinish(req models.WsRequest, rec models.PlayRecord, err error) {
+	logp := newLogParams(req.ConnId)
+	logp.Prefix = fmt.Sprintf("<%s>", models.FINISH.String())
+
+	a.finishing = false
+	pc, ok := PlayerPool.Get(req.ConnId)
+
+	// プレイ記録が保存できなければ、部屋を閉じずにエラー
+	if err != nil {
+		logp.IsProcError = true
+		logp.log(fmt.Sprintf("プレイ記録の保存に失敗しました: %s", err))
+
+		if ok {
+			pc.sendError(models.ErrPlayNotSaved, logp)
+		}
+
+		return
+	}
+
+	res := models.FinishResponse{
+		RoomId: a.id,
+		Record: rec,
+	}
+
+	logp.Method = models.OK
+	response := models.WsResponse{
+		Method: models.OK.String(),
+		Params: res,
+	}
+
+	if ok {
+		pc.sendJson(response, logp)
+	}
+
+	response.Method = models.FINISH.String()
+	a.send(response, models.FINISH, req.ConnId)
+
+	room := cloneRoom(a.room)
+
+	// 全員を退室させ、部屋を閉じる
+	for _, s := range room.Spectators {
+		a.eject(s.ConnId, "finished")
+	}
+
+	for _, p := range room.Players {
+		a.eject(p.ConnId, "finished")
+	}
+}
+
+// <summary>: [Method] RESUME に関する動作を定義します
+// <remark>: 席の検索はロビーで行い、引き継ぎは部屋のアクターで行う
+func actionResume(req models.WsRequest) {
+	logp := newLogParams(req.ConnId)
+	logp.Prefix = fmt.Sprintf("<%s>", models.RESUME.String())
+
+	pc, ok := PlayerPool.Get(req.ConnId)
+	if !ok {
+		logp.IsProcError = true
+		logp.log("送信されたconnection_idが不正です")
+
+		return
+	}
+
+	// 別室に既に入室していればエラー
+	if pc.RoomId != "" {
+		pc.sendError(models.ErrEnteredAnotherRoom, logp)
+		return
+	}
+
+	oldid, old, exist := PlayerPool.GetByResumeToken(req.ResumeToken)
+
+	// 再開できる席がなければエラー
+	if !exist || oldid == req.ConnId || old.RoomId == "" {
+		pc.sendError(models.ErrSeatNotFound, logp)
+		return
+	}
+
+	switch rooms.tryDispatch(old.RoomId, req, actionTakeOver) {
+	case roomMissing:
+		pc.sendError(models.ErrSeatNotFound, logp)
+
+	case roomBusy:
+		pc.sendError(models.ErrRoomBusy, logp)
+	}
+}
+
+// <summary>: 保持している席を新しい接続へ引き継ぎます
+// <remark>: 引き継ぎ元の接続が残っていれば切断します
+func actionTakeOver(a *roomActor, req models.WsRequest) {
+	logp := newLogParams(req.ConnId)
+	logp.Prefix = fmt.Sprintf("<%s>", models.RESUME.String())
+
+	pc, ok := PlayerPool.Get(req.ConnId)
+	if !ok {
+		logp.IsProcError = true
+		logp.log("送信されたconnection_idが不正です")
+
+		return
+	}
+
+	oldid, old, exist := PlayerPool.GetByResumeToken(req.ResumeToken)
+	i := a.indexOf(oldid)
+
+	// ロビーでの検索後に席が解放されていればエラー
+	if !exist || old.RoomId != a.id || i < 0 {
+		pc.sendError(models.ErrSeatNotFound, logp)
+		return
+	}
+
+	adopted := PlayerPool.Update(req.ConnId, func(p *PlayerConn) bool {
+		if p.RoomId != "" {
+			return false
+		}
+
+		p.PlayerId = old.PlayerId
+		p.RoomId = a.id
+		return true
+	})
+
+	// 他の部屋への入室と競合していればエラー
+	if !adopted {
+		pc.sendError(models.ErrEnteredAnotherRoom, logp)
+		return
+	}
+
+	if old.expire != nil {
+		old.expire.Stop()
+	}
+
+	PlayerPool.Delete(oldid)
+	old.close()
+
+	a.room.Players[i].ConnId = req.ConnId
+	a.room.Players[i].IsConnected = true
+
+	// 引き継いだPlayerIdでログを出力する
+	pc, _ = PlayerPool.Get(req.ConnId)
+	logp.PlayerId = pc.PlayerId
+
+	res := a.response()
+	res.History = res.RoomInfo.History
+
+	logp.Method = models.OK
+	response := models.WsResponse{
+		Method: models.OK.String(),
+		Params: res,
+	}
+
+	pc.sendJson(response, logp)
+	a.notify(req.ConnId)
+}
+
+// <summary>: [Method] BROADCAST に関する動作を定義します
+func actionBroadcast(a *roomActor, req models.WsRequest) {
+	logp := newLogParams(req.ConnId)
+	logp.Prefix = fmt.Sprintf("<%s>", models.BROADCAST.String())
+
+	pc, ok := PlayerPool.Get(req.ConnId)
+	if !ok {
+		logp.IsProcError = true
+		logp.log("送信されたconnection_idが不正です")
+
+		return
+	}
+
+	// リクエストされた部屋情報とゲームが不一致であればエラー
+	if a.room.GameId != req.GameId {
+		pc.sendError(models.ErrMismatchGame, logp)
+		return
+	}
+
+	i := a.indexOf(req.ConnId)
+
+	// 部屋にプレイヤーが入室していなければエラー
+	if i < 0 {
+		pc.sendError(models.ErrNotInRoom, logp)
+		return
+	}
+
+	// プレイ記録の保存中であればエラー
+	if a.finishing {
+		pc.sendError(models.ErrFinishing, logp)
+		return
+	}
+
+	// 得点が得点表の定義に合致しなければエラー
+	if !isValidPoints(a.room.GameData.Categories, req.Points) {
+		pc.sendError(models.ErrInvalidPoints, logp)
+		return
+	}
+
+	// 最新の得点と変更履歴を部屋で保持する
+	change := a.recordPoints(i, req.Points)
+
+	// 新たな変更があれば、やり直しはできなくなる
+	a.room.Redo = nil
+
 	point := models.PointResponse{
 		Points: req.Points,
-		Player: models.PlayerInfoSet{
-			ConnId:      req.ConnId,
-			PlayerColor: req.PlayerColor,
-		},
+		Total:  a.room.Players[i].Total,
+		Player: a.room.Players[i],
+		Change: change,
+		Result: a.room.Result,
 	}
 
 	logp.Method = models.OK
@@ -250,23 +822,195 @@ func actionBroadcast(req models.WsRequest) {
 
 	pc.sendJson(response, logp)
 
-	for _, p := range room.Players {
-		if p.ConnId == req.ConnId {
-			continue
-		}
+	response.Method = models.BROADCAST.String()
+	a.send(response, models.BROADCAST, req.ConnId)
+}
 
-		inpc, ex := PlayerPool.Get(p.ConnId)
-		if !ex {
-			continue
-		}
+// <summary>: [Method] UNDO に関する動作を定義します
+// <remark>: seqが指定されていれば、取り消し対象の変更と一致する場合のみ取り消す
+func actionUndo(a *roomActor, req models.WsRequest) {
+	logp := newLogParams(req.ConnId)
+	logp.Prefix = fmt.Sprintf("<%s>", models.UNDO.String())
+
+	pc, ok := PlayerPool.Get(req.ConnId)
+	if !ok {
+		logp.IsProcError = true
+		logp.log("送信されたconnection_idが不正です")
+
+		return
+	}
+
+	// 部屋にプレイヤーが入室していなければエラー
+	if a.indexOf(req.ConnId) < 0 {
+		pc.sendError(models.ErrNotInRoom, logp)
+		return
+	}
+
+	// プレイ記録の保存中であればエラー
+	if a.finishing {
+		pc.sendError(models.ErrFinishing, logp)
+		return
+	}
+
+	target := ""
+	if req.OwnOnly {
+		target = pc.PlayerId
+	}
+
+	k := lastChange(a.room.History, target)
+
+	// 取り消せる変更がなければエラー
+	if k < 0 {
+		pc.sendError(models.ErrNothingToUndo, logp)
+		return
+	}
+
+	change := a.room.History[k]
 
-		l := newLogParams(p.ConnId)
-		l.Method = models.BROADCAST
-		l.Prefix = logp.Prefix
-		response.Method = models.BROADCAST.String()
+	// 他のプレイヤーが先に履歴を更新していればエラー
+	if req.Seq != 0 && req.Seq != change.Seq {
+		pc.sendError(models.ErrScoreConflict, logp)
+		return
+	}
+
+	a.room.History = append(a.room.History[:k], a.room.History[k+1:]...)
+	a.room.Redo = append(a.room.Redo, change)
+
+	point := models.PointResponse{
+		Points: change.Previous,
+		Change: change,
+	}
+
+	// 退室済みのプレイヤーであれば履歴のみを取り消す
+	if i := a.indexOfPlayer(change.PlayerId); i >= 0 {
+		a.setPoints(i, change.Previous)
+		point.Total = a.room.Players[i].Total
+		point.Player = a.room.Players[i]
+
+	} else {
+		point.Player = models.PlayerInfoSet{PlayerId: change.PlayerId}
+	}
+
+	point.Result = a.room.Result
 
-		inpc.sendJson(response, l)
+	logp.Method = models.OK
+	response := models.WsResponse{
+		Method: models.OK.String(),
+		Params: point,
 	}
+
+	pc.sendJson(response, logp)
+
+	response.Method = models.UNDO.String()
+	a.send(response, models.UNDO, req.ConnId)
+}
+
+// <summary>: [Method] REDO に関する動作を定義します
+// <remark>: seqが指定されていれば、やり直し対象の変更と一致する場合のみやり直す
+func actionRedo(a *roomActor, req models.WsRequest) {
+	logp := newLogParams(req.ConnId)
+	logp.Prefix = fmt.Sprintf("<%s>", models.REDO.String())
+
+	pc, ok := PlayerPool.Get(req.ConnId)
+	if !ok {
+		logp.IsProcError = true
+		logp.log("送信されたconnection_idが不正です")
+
+		return
+	}
+
+	// 部屋にプレイヤーが入室していなければエラー
+	if a.indexOf(req.ConnId) < 0 {
+		pc.sendError(models.ErrNotInRoom, logp)
+		return
+	}
+
+	// プレイ記録の保存中であればエラー
+	if a.finishing {
+		pc.sendError(models.ErrFinishing, logp)
+		return
+	}
+
+	target := ""
+	if req.OwnOnly {
+		target = pc.PlayerId
+	}
+
+	k := lastChange(a.room.Redo, target)
+
+	// やり直せる変更がなければエラー
+	if k < 0 {
+		pc.sendError(models.ErrNothingToRedo, logp)
+		return
+	}
+
+	undone := a.room.Redo[k]
+
+	// 他のプレイヤーが先に履歴を更新していればエラー
+	if req.Seq != 0 && req.Seq != undone.Seq {
+		pc.sendError(models.ErrScoreConflict, logp)
+		return
+	}
+
+	i := a.indexOfPlayer(undone.PlayerId)
+
+	// 退室済みのプレイヤーの変更はやり直せない
+	if i < 0 {
+		pc.sendError(models.ErrNothingToRedo, logp)
+		return
+	}
+
+	change := a.recordPoints(i, undone.Current)
+
+	// やり直した変更は、反映してから取り除く
+	a.room.Redo = append(a.room.Redo[:k], a.room.Redo[k+1:]...)
+
+	point := models.PointResponse{
+		Points: change.Current,
+		Total:  a.room.Players[i].Total,
+		Player: a.room.Players[i],
+		Change: change,
+		Result: a.room.Result,
+	}
+
+	logp.Method = models.OK
+	response := models.WsResponse{
+		Method: models.OK.String(),
+		Params: point,
+	}
+
+	pc.sendJson(response, logp)
+
+	response.Method = models.REDO.String()
+	a.send(response, models.REDO, req.ConnId)
+}
+
+// <summary>: [Method] HISTORY に関する動作を定義します
+func actionHistory(a *roomActor, req models.WsRequest) {
+	logp := newLogParams(req.ConnId)
+	logp.Prefix = fmt.Sprintf("<%s>", models.HISTORY.String())
+
+	pc, ok := PlayerPool.Get(req.ConnId)
+	if !ok {
+		logp.IsProcError = true
+		logp.log("送信されたconnection_idが不正です")
+
+		return
+	}
+
+	// 部屋にプレイヤー、観戦者ともに入室していなければエラー
+	if a.indexOf(req.ConnId) < 0 && a.indexOfSpectator(req.ConnId) < 0 {
+		pc.sendError(models.ErrNotInRoom, logp)
+		return
+	}
+
+	logp.Method = models.OK
+	response := models.WsResponse{
+		Method: models.OK.String(),
+		Params: a.history(),
+	}
+
+	pc.sendJson(response, logp)
 }
 
 // <summary>: [Method] NONE に関する動作を定義します
@@ -284,3 +1028,75 @@ func actionNone(req models.WsRequest) {
 
 	pc.sendError(models.ErrInvalidMethod, logp)
 }
+
+// <summary>: 部屋宛てのリクエストを部屋のアクターへ渡します
+func dispatchToRoom(req models.WsRequest, action func(*roomActor, models.WsRequest)) {
+	result := rooms.tryDispatch(req.RoomId, req, action)
+	if result == dispatched {
+		return
+	}
+
+	logp := newLogParams(req.ConnId)
+	logp.Prefix = fmt.Sprintf("<%s>", models.ParseMethod(req.Method).String())
+
+	pc, ok := PlayerPool.Get(req.ConnId)
+	if !ok {
+		logp.IsProcError = true
+		logp.log("送信されたconnection_idが不正です")
+
+		return
+	}
+
+	// 部屋の処理待ちが溢れていればエラー
+	if result == roomBusy {
+		pc.sendError(models.ErrRoomBusy, logp)
+		return
+	}
+
+	// リクエストされた部屋情報がなければエラー
+	pc.sendError(models.ErrRoomNotFound, logp)
+}
+
+// <summary>: 接続に部屋を紐付けます
+// <remark>: 既に別室へ入室していればfalseを返す
+func claimRoom(connid, roomid string) bool {
+	return PlayerPool.Update(connid, func(p *PlayerConn) bool {
+		if p.RoomId != "" {
+			return false
+		}
+
+		p.RoomId = roomid
+		return true
+	})
+}
+
+// <summary>: ボードゲームで使用できる色か確認します
+func isColorOffered(colors []string, color string) bool {
+	for _, c := range colors {
+		if c == color {
+			return true
+		}
+	}
+
+	return false
+}
+
+// <summary>: 得点が得点表の定義に合致するか確認します
+// <remark>: 得点表が定義されていないボードゲームでは検証しない
+func isValidPoints(cats []models.ScoreCategory, points []int) bool {
+	if len(cats) == 0 {
+		return true
+	}
+
+	if len(points) != len(cats) {
+		return false
+	}
+
+	for i, c := range cats {
+		if points[i] < c.Min || c.Max < points[i] {
+			return false
+		}
+	}
+
+	return true
+}
@@ -49,6 +49,40 @@ func (p *PlayerMap) Get(id string) (PlayerConn, bool) {
 	return v, ok
 }
 
+// <summary>: プレイヤーマップからPlayerIdをもとに情報を取得します
+// <remark>: 該当する接続のConnIdも併せて取得できます
+func (p *PlayerMap) GetByPlayerId(playerid string) (string, PlayerConn, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for k, v := range p.m {
+		if v.PlayerId == playerid {
+			return k, v, true
+		}
+	}
+
+	return "", PlayerConn{}, false
+}
+
+// <summary>: プレイヤーマップから再開用トークンをもとに情報を取得します
+// <remark>: 該当する接続のConnIdも併せて取得できます
+func (p *PlayerMap) GetByResumeToken(token string) (string, PlayerConn, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if token == "" {
+		return "", PlayerConn{}, false
+	}
+
+	for k, v := range p.m {
+		if v.ResumeToken == token {
+			return k, v, true
+		}
+	}
+
+	return "", PlayerConn{}, false
+}
+
 // <summary>: プレイヤーマップに情報を格納します
 func (p *PlayerMap) Set(id string, conn PlayerConn) {
 	p.mu.Lock()
@@ -73,6 +107,21 @@ func (p *PlayerMap) SetRoomId(connid, roomid string) bool {
 	return ok
 }
 
+// <summary>: プレイヤーマップの情報を排他的に更新します
+// <remark>: fがfalseを返した場合は更新せず、更新の成否が取得できます
+func (p *PlayerMap) Update(id string, f func(*PlayerConn) bool) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	v, ok := p.m[id]
+	if !ok || !f(&v) {
+		return false
+	}
+
+	p.m[id] = v
+	return true
+}
+
 // <summary>: プレイヤーマップから情報を削除します
 func (p *PlayerMap) Delete(id string) {
 	p.mu.Lock()
@@ -82,19 +131,31 @@ func (p *PlayerMap) Delete(id string) {
 }
 
 // <summary>: プレイヤーマップからプレイヤーがいる部屋情報の一覧を取得します
+// <remark>: キーはPlayerId
 func (p *PlayerMap) PlayerRoomData() map[string]string {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
 	result := make(map[string]string, len(p.m))
 
-	for k, v := range p.m {
-		result[k] = v.RoomId
+	for _, v := range p.m {
+		result[v.PlayerId] = v.RoomId
 	}
 
 	return result
 }
 
+// <summary>: プレイヤーマップの情報に対して、一連の処理を実行します
+// <remark>: fの中でプレイヤーマップを操作してはならない
+func (p *PlayerMap) Range(f func(id string, conn PlayerConn)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for k, v := range p.m {
+		f(k, v)
+	}
+}
+
 // <summary>: 部屋マップにあるデータの数を数えます
 func (r *RoomMap) Count() int {
 	r.mu.RLock()
@@ -0,0 +1,58 @@
+package ws
+
+import (
+	"time"
+)
+
+const (
+	// 応答のない接続を切断するまでの時間の既定値
+	defaultIdleTimeout time.Duration = 60 * time.Second
+
+	// 操作のない部屋を閉じるまでの時間の既定値
+	defaultRoomIdleTimeout time.Duration = 3 * time.Hour
+
+	// 切断後に席を保持しておく猶予期間の既定値
+	defaultResumeGracePeriod time.Duration = 2 * time.Minute
+
+	// 部屋が閉じられた後、部屋IDを再利用しない期間の既定値
+	defaultRoomCodeRetention time.Duration = 24 * time.Hour
+)
+
+// <summary>: 接続と部屋の時間に関する設定
+type Timeouts struct {
+	Idle              time.Duration
+	RoomIdle          time.Duration
+	ResumeGrace       time.Duration
+	RoomCodeRetention time.Duration
+}
+
+// <summary>: 接続と部屋の時間に関する設定の既定値を取得します
+func DefaultTimeouts() Timeouts {
+	return Timeouts{
+		Idle:              defaultIdleTimeout,
+		RoomIdle:          defaultRoomIdleTimeout,
+		ResumeGrace:       defaultResumeGracePeriod,
+		RoomCodeRetention: defaultRoomCodeRetention,
+	}
+}
+
+// <summary>: 接続と部屋の時間に関する設定を反映します
+// <remark>: 設定値は各goroutineから同期せずに参照するため、RestoreSnapshotとServeRequestより前に一度だけ呼び出す
+// <remark>: 0以下の値は無視し、既定値のままとする
+func Configure(t Timeouts) {
+	if t.Idle > 0 {
+		idleTimeout = t.Idle
+	}
+
+	if t.RoomIdle > 0 {
+		roomIdleTimeout = t.RoomIdle
+	}
+
+	if t.ResumeGrace > 0 {
+		resumeGracePeriod = t.ResumeGrace
+	}
+
+	if t.RoomCodeRetention > 0 {
+		roomCodeRetention = t.RoomCodeRetention
+	}
+}
@@ -1,18 +1,13 @@
 package ws
 
 import (
-	"crypto/sha256"
-	"encoding/binary"
+	"crypto/rand"
+	"encoding/base64"
 	"encoding/hex"
-	"errors"
-	"fmt"
 	"net"
-	"strconv"
-	"strings"
+	"sync/atomic"
 
 	hashids "github.com/speps/go-hashids"
-
-	"unsafe"
 )
 
 const (
@@ -21,177 +16,80 @@ const (
 
 	// Hashidsの最低文字列長
 	minLength int = 4
+
+	// 接続トークンのバイト長
+	tokenBytes int = 32
 )
 
-// <summary>: Hash(SHA256)値を取得します
-func getHash(remote string) string {
-	// hash := sha256.Sum256([]byte(str))
-	hash := sha256.Sum256(*(*[]byte)(unsafe.Pointer(&remote)))
-	return hex.EncodeToString(hash[:])
+var (
+	// <summary>: PlayerIdの払い出しに使用する連番
+	playerSeq int64 = 0
+
+	// <summary>: PlayerIdの生成に使用するSalt（起動毎に変わる）
+	playerSalt = newPlayerSalt()
+
+	// <summary>: PlayerIdの生成器
+	playerHashId = newPlayerHashId(playerSalt)
+)
+
+// <summary>: PlayerId生成用のSaltを生成します
+func newPlayerSalt() string {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		panic(err)
+	}
+
+	return hex.EncodeToString(salt)
 }
 
-// <summary>: ConnIdを取得します
-func getConnId(remote string) (string, error) {
+// <summary>: PlayerId生成用のHashidsを初期化します
+func newPlayerHashId(salt string) *hashids.HashID {
 	d := hashids.NewData()
 	d.Alphabet = alphabet
 	d.MinLength = minLength
-	d.Salt = getHash(remote)
+	d.Salt = salt
 
 	hid, err := hashids.NewWithData(d)
 	if err != nil {
-		return "", err
+		panic(err)
 	}
 
-	ip, port, err := addressToIpPort(remote)
-	if err != nil {
-		return "", err
-	}
-
-	var num int64 = 0
+	return hid
+}
 
-	for i := 0; i < 4; i++ {
-		st := 4 * i
-		ed := 4 * (i + 1)
-		num += int64(binary.BigEndian.Uint32(ip[st:ed]))
-	}
-	num += int64(port)
+// <summary>: 推測不可能な接続トークン（ConnId）を生成します
+// <remark>: 本人にのみ通知し、他のプレイヤーには公開しない
+func newConnToken() (string, error) {
+	b := make([]byte, tokenBytes)
 
-	hashid, err := hid.EncodeInt64([]int64{num})
-	if err != nil {
+	if _, err := rand.Read(b); err != nil {
 		return "", err
 	}
 
-	com := compressedString(ip, port)
-
-	return fmt.Sprintf("%s-%s", hashid, com), nil
+	return base64.RawURLEncoding.EncodeToString(b), nil
 }
 
-// <summary>: アドレスからIPアドレスとポート番号を抽出します
-func addressToIpPort(remote string) ([]byte, uint16, error) {
-	h, p, err := net.SplitHostPort(remote)
-	if err != nil {
-		return []byte{}, 0, err
-	}
-
-	ip := net.ParseIP(h)
-	if ip == nil {
-		e := errors.New("invalid host address")
-		return []byte{}, 0, e
-	}
-
-	port, err := strconv.Atoi(p)
-	if err != nil {
-		return []byte{}, 0, err
-	}
+// <summary>: 他のプレイヤーへ公開できるPlayerIdを生成します
+func newPlayerId() (string, error) {
+	seq := atomic.AddInt64(&playerSeq, 1)
 
-	return []byte(ip.To16()), uint16(port), nil
+	return playerHashId.EncodeInt64([]int64{seq})
 }
 
-// <summary>: ConnIdが正しいか検証します（簡易的）
-func isCorrectConnId(connid, remote string) bool {
-	h, p, err := net.SplitHostPort(remote)
-	if err != nil {
-		return false
-	}
-
-	ip, port := getIpPort(connid)
-
-	return ip == h && port == p
+// <summary>: 退避していたSaltと連番でPlayerIdの生成器を復元します
+// <remark>: 復元した席のPlayerIdと、新たに払い出すPlayerIdが衝突しないようにする
+func restorePlayerIds(salt string, seq int64) {
+	playerSalt = salt
+	playerHashId = newPlayerHashId(salt)
+	atomic.StoreInt64(&playerSeq, seq)
 }
 
-// <summary>: ConnIdからIPアドレスとポート番号を取得します
-func getIpPort(connid string) (string, string) {
-	cutid := strings.Split(connid, "-")
-	if len(cutid) != 2 {
-		return "", ""
-	}
-
-	splited := strings.Split(cutid[1], ".")
-	if len(splited) < 2 {
-		return "", ""
-	}
-
-	var result strings.Builder
-	result.Grow(32)
-
-	for i, s := range splited {
-		if i % 2 == 0 {
-			result.WriteString(s)
-
-		} else {
-			length := len(splited[i-1])
-			last := string(splited[i-1][length-1])
-			count, _ := strconv.Atoi(s)
-
-			str := make([]string, count)
-			for j := 0; j < count; j++ {
-				str[j] = last
-			}
-
-			result.WriteString(strings.Join(str, ""))
-		}
-	}
-
-	dec, err := hex.DecodeString(result.String())
+// <summary>: アドレスからIPアドレスを抽出します
+func remoteIp(remote string) string {
+	h, _, err := net.SplitHostPort(remote)
 	if err != nil {
-		return "", ""
-	}
-
-	ip := net.IP(dec[2:]).String()
-	port := fmt.Sprint(binary.BigEndian.Uint16(dec[:2]))
-
-	return ip, port
-}
-
-// <summary>: IPアドレスとポート番号を圧縮された文字列に変換します
-func compressedString(ip []byte, port uint16) string {
-	conv := make([]byte, 2, 18)
-	binary.BigEndian.PutUint16(conv, port)
-
-	conv = append(conv, ip...)
-	enc := hex.EncodeToString(conv)
-
-	var result strings.Builder
-	var buf strings.Builder
-	var forward rune
-
-	result.Grow(36)
-	buf.Grow(36)
-
-	action := func(r rune) {
-		if buf.Len() == 0 {
-			result.WriteRune(r)
-
-		} else if buf.Len() <= 3 {
-			result.WriteString(buf.String())
-			result.WriteRune(r)
-
-		} else {
-			result.WriteRune(r)
-			result.WriteString(fmt.Sprintf(".%d.", buf.Len()))
-		}
-	}
-
-	for i, r := range enc {
-		if i == 0 {
-			forward = r
-			continue
-		}
-
-		if forward == r {
-			buf.WriteRune(forward)
-
-		} else {
-			action(forward)
-			buf.Reset()
-		}
-
-		if i == len([]rune(enc)) - 1 {
-			action(r)
-		}
-
-		forward = r
+		return remote
 	}
 
-	return result.String()
+	return h
 }
@@ -0,0 +1,80 @@
+package ws
+
+import (
+	"bgtools-api/models"
+)
+
+// <summary>: 基本のボードゲームと拡張を組み合わせたボードゲーム情報を作成します
+// <remark>: プレイ人数は全体の範囲とし、色は基本のボードゲームの色に続けて重複を除いて並べる
+// <remark>: 得点表は基本のボードゲームのものを使用する
+// <remark>: 拡張のみで部屋を作成することはできない
+func combineGameData(catalog map[string]models.BgPartialData, gameId string, expansions []string) (models.BgPartialData, models.ErrorMessage, bool) {
+	data, exist := catalog[gameId]
+
+	// リクエストされたボードゲーム情報がない、もしくは拡張であればエラー
+	if !exist || data.IsExpansion {
+		return models.BgPartialData{}, models.ErrBoardgameNotFound, false
+	}
+
+	if len(expansions) == 0 {
+		return data, models.ErrorMessage{}, true
+	}
+
+	// 格納済みのmapを変更しないよう、色は複製してから追加する
+	colors := make([]string, len(data.Colors), len(data.Colors)+len(expansions))
+	copy(colors, data.Colors)
+
+	seen := make(map[string]bool, len(expansions))
+
+	for _, id := range expansions {
+		exp, ok := catalog[id]
+
+		// 指定されたボードゲームの拡張でない、もしくは重複していればエラー
+		if !ok || !exp.IsExpansion || exp.BaseId != gameId || seen[id] {
+			return models.BgPartialData{}, models.ErrInvalidExpansion, false
+		}
+
+		seen[id] = true
+
+		if exp.MinPlayers < data.MinPlayers {
+			data.MinPlayers = exp.MinPlayers
+		}
+
+		if exp.MaxPlayers > data.MaxPlayers {
+			data.MaxPlayers = exp.MaxPlayers
+		}
+
+		for _, col := range exp.Colors {
+			if !isColorOffered(colors, col) {
+				colors = append(colors, col)
+			}
+		}
+	}
+
+	data.Colors = colors
+
+	return data, models.ErrorMessage{}, true
+}
+
+// <summary>: 2つの拡張の指定が、順序を問わず一致するか確認します
+func isSameExpansions(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	count := make(map[string]int, len(a))
+
+	for _, id := range a {
+		count[id]++
+	}
+
+	for _, id := range b {
+		if count[id] == 0 {
+			return false
+		}
+
+		count[id]--
+	}
+
+	return true
+}
@@ -0,0 +1,174 @@
+package ws
+
+import (
+	"reflect"
+	"testing"
+
+	"bgtools-api/models"
+)
+
+func TestCombineGameData(t *testing.T) {
+	catalog := map[string]models.BgPartialData{
+		"base": {
+			Title:      "基本",
+			MinPlayers: 3,
+			MaxPlayers: 4,
+			Colors:     []string{"red", "blue", "green", "yellow"},
+			Categories: []models.ScoreCategory{{Name: "点数", Weight: 1, IsTotal: true}},
+		},
+		"more": {
+			Title:       "5-6人用拡張",
+			MinPlayers:  5,
+			MaxPlayers:  6,
+			IsExpansion: true,
+			BaseId:      "base",
+			Colors:      []string{"red", "white", "black"},
+		},
+		"solo": {
+			Title:       "ソロ拡張",
+			MinPlayers:  1,
+			MaxPlayers:  1,
+			IsExpansion: true,
+			BaseId:      "base",
+		},
+		"other": {
+			Title:      "別のボードゲーム",
+			MinPlayers: 2,
+			MaxPlayers: 2,
+			Colors:     []string{"red", "blue"},
+		},
+		"otherexp": {
+			Title:       "別のボードゲームの拡張",
+			MinPlayers:  2,
+			MaxPlayers:  3,
+			IsExpansion: true,
+			BaseId:      "other",
+			Colors:      []string{"green"},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		gameId     string
+		expansions []string
+		wantErr    models.ErrorMessage
+		wantMin    int
+		wantMax    int
+		wantColors []string
+	}{
+		{
+			name:       "拡張なし",
+			gameId:     "base",
+			wantMin:    3,
+			wantMax:    4,
+			wantColors: []string{"red", "blue", "green", "yellow"},
+		},
+		{
+			name:       "色は重複を除いて後ろに並べる",
+			gameId:     "base",
+			expansions: []string{"more"},
+			wantMin:    3,
+			wantMax:    6,
+			wantColors: []string{"red", "blue", "green", "yellow", "white", "black"},
+		},
+		{
+			name:       "プレイ人数は全体の範囲とする",
+			gameId:     "base",
+			expansions: []string{"solo", "more"},
+			wantMin:    1,
+			wantMax:    6,
+			wantColors: []string{"red", "blue", "green", "yellow", "white", "black"},
+		},
+		{
+			name:    "存在しないボードゲーム",
+			gameId:  "none",
+			wantErr: models.ErrBoardgameNotFound,
+		},
+		{
+			name:    "拡張のみでは作成できない",
+			gameId:  "more",
+			wantErr: models.ErrBoardgameNotFound,
+		},
+		{
+			name:       "存在しない拡張",
+			gameId:     "base",
+			expansions: []string{"none"},
+			wantErr:    models.ErrInvalidExpansion,
+		},
+		{
+			name:       "別のボードゲームの拡張",
+			gameId:     "base",
+			expansions: []string{"otherexp"},
+			wantErr:    models.ErrInvalidExpansion,
+		},
+		{
+			name:       "拡張でないボードゲーム",
+			gameId:     "base",
+			expansions: []string{"other"},
+			wantErr:    models.ErrInvalidExpansion,
+		},
+		{
+			name:       "重複した拡張",
+			gameId:     "base",
+			expansions: []string{"more", "more"},
+			wantErr:    models.ErrInvalidExpansion,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, errMsg, ok := combineGameData(catalog, tt.gameId, tt.expansions)
+
+			if tt.wantErr != (models.ErrorMessage{}) {
+				if ok || errMsg != tt.wantErr {
+					t.Fatalf("combineGameData() = %v, %v, want %v", errMsg, ok, tt.wantErr)
+				}
+
+				return
+			}
+
+			if !ok {
+				t.Fatalf("combineGameData() = %v, want ok", errMsg)
+			}
+
+			if data.MinPlayers != tt.wantMin || data.MaxPlayers != tt.wantMax {
+				t.Errorf("players = %d-%d, want %d-%d", data.MinPlayers, data.MaxPlayers, tt.wantMin, tt.wantMax)
+			}
+
+			if !reflect.DeepEqual(data.Colors, tt.wantColors) {
+				t.Errorf("colors = %v, want %v", data.Colors, tt.wantColors)
+			}
+
+			if !reflect.DeepEqual(data.Categories, catalog["base"].Categories) {
+				t.Errorf("categories = %v, want %v", data.Categories, catalog["base"].Categories)
+			}
+		})
+	}
+
+	// 組み合わせても格納済みの情報は変更しない
+	if want := []string{"red", "blue", "green", "yellow"}; !reflect.DeepEqual(catalog["base"].Colors, want) {
+		t.Errorf("catalog colors = %v, want %v", catalog["base"].Colors, want)
+	}
+}
+
+func TestIsSameExpansions(t *testing.T) {
+	tests := []struct {
+		name string
+		a    []string
+		b    []string
+		want bool
+	}{
+		{"どちらも空", nil, []string{}, true},
+		{"順序が異なる", []string{"x", "y"}, []string{"y", "x"}, true},
+		{"数が異なる", []string{"x"}, []string{"x", "y"}, false},
+		{"重複の数が異なる", []string{"x", "x"}, []string{"x", "y"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isSameExpansions(tt.a, tt.b); got != tt.want {
+				t.Errorf("isSameExpansions(%v, %v) = %v, want %v", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
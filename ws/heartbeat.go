@@ -0,0 +1,81 @@
+package ws
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sync/atomic"
+	"time"
+
+	"bgtools-api/models"
+
+	"github.com/gorilla/websocket"
+)
+
+var (
+	// <summary>: 応答のない接続を切断するまでの時間
+	idleTimeout time.Duration = defaultIdleTimeout
+
+	// <summary>: 刈り取った（正常に切断されなかった）接続の数
+	reapedCount int64 = 0
+)
+
+// <summary>: 応答のない接続を切断するまでの時間を取得します
+func IdleTimeout() time.Duration {
+	return idleTimeout
+}
+
+// <summary>: 起動してから刈り取った接続の数を取得します
+func ReapedCount() int64 {
+	return atomic.LoadInt64(&reapedCount)
+}
+
+// <summary>: Pingを送信する間隔を取得します
+// <remark>: 応答のない接続を切断するまでの時間の9割とする
+func pingPeriod() time.Duration {
+	return idleTimeout * 9 / 10
+}
+
+// <summary>: 受信の期限を延長します
+func extendReadDeadline(conn *websocket.Conn) error {
+	return conn.SetReadDeadline(time.Now().Add(idleTimeout))
+}
+
+// <summary>: 受信に失敗した理由をログに出力します
+// <remark>: クライアントからの正常な切断と停止処理以外は刈り取った接続として数える
+func logReadError(err error, logp logParams) {
+	logp.Method = models.DISCONNECT
+
+	// サーバの停止による切断は刈り取りとして数えない
+	if IsShuttingDown() {
+		logp.Prefix = "[close]"
+		logp.log("サーバの停止により接続を切断しました")
+
+		return
+	}
+
+	if websocket.IsCloseError(err,
+		websocket.CloseNormalClosure,
+		websocket.CloseGoingAway,
+		websocket.CloseNoStatusReceived) {
+
+		logp.Prefix = "[close]"
+		logp.log(fmt.Sprintf("接続が切断されました: %s", err))
+
+		return
+	}
+
+	var ne net.Error
+
+	if errors.As(err, &ne) && ne.Timeout() {
+		logp.Prefix = "[idle]"
+		logp.log("一定時間応答がないため、接続を切断しました")
+
+	} else {
+		logp.Prefix = "[reap]"
+		logp.IsProcError = true
+		logp.log(fmt.Sprintf("メッセージの受信に失敗したため、接続を切断しました: %s", err))
+	}
+
+	atomic.AddInt64(&reapedCount, 1)
+}
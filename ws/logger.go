@@ -29,7 +29,7 @@ var (
 // <summary>: WebSocket用ログのパラメータを示す構造体
 type logParams struct {
 	ClientIP    string
-	ConnId      string
+	PlayerId    string
 	Prefix      string
 	Method      models.Method
 	IsProcError bool
@@ -52,12 +52,13 @@ func ChangeOutputDestination(dest io.Writer) {
 }
 
 // <summary>: 新規logParams構造体を生成します
+// <remark>: 接続情報からIPアドレスとPlayerIdを補完する
 func newLogParams(connid string) logParams {
-	ip, _ := getIpPort(connid)
+	pc, _ := PlayerPool.Get(connid)
 
 	return logParams{
-		ClientIP:    ip,
-		ConnId:      connid,
+		ClientIP:    pc.ClientIP,
+		PlayerId:    pc.PlayerId,
 		Method:      models.NONE,
 		IsProcError: false,
 	}
@@ -85,7 +86,7 @@ func (p logParams) log(message string) {
 			tag,
 			time.Now().Format("2006/01/02 - 15:04:05"),
 			p.ClientIP,
-			p.ConnId,
+			p.PlayerId,
 			redColor, resetColor, message,
 		)
 
@@ -99,7 +100,7 @@ func (p logParams) log(message string) {
 			tag,
 			time.Now().Format("2006/01/02 - 15:04:05"),
 			p.ClientIP,
-			p.ConnId,
+			p.PlayerId,
 			p.methodColor(), p.Method.String(), resetColor,
 			prefix, message,
 		)
@@ -125,9 +126,33 @@ func (p logParams) methodColor() string {
 	case models.LEAVE:
 		return magenta
 
+	case models.RESUME:
+		return cyan
+
+	case models.WATCH:
+		return cyan
+
+	case models.RENAME:
+		return cyan
+
+	case models.RECOLOR:
+		return cyan
+
+	case models.FINISH:
+		return blue
+
+	case models.SHUTDOWN:
+		return magenta
+
 	case models.BROADCAST:
 		return yellow
 
+	case models.UNDO:
+		return yellow
+
+	case models.REDO:
+		return yellow
+
 	case models.CONNECT:
 		return bgreen
 
@@ -0,0 +1,206 @@
+package ws
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"bgtools-api/models"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// 合言葉のハッシュ化のコスト
+	passphraseCost int = bcrypt.DefaultCost
+
+	// 合言葉の最大バイト長（bcryptで扱える長さ）
+	maxPassphraseBytes int = 72
+
+	// 合言葉の検証を一時的に拒否するまでの連続失敗回数
+	passFailureLimit int = 5
+
+	// 連続して失敗した後、合言葉の検証を拒否する時間
+	// 最後の失敗からこの時間が経過すれば、失敗回数も数え直す
+	passLockout time.Duration = time.Minute
+
+	// 検証の失敗記録を整理し始める件数
+	passGuardPrune int = 1024
+)
+
+// <summary>: 合言葉の検証の失敗記録
+type passGuard struct {
+	failures int
+	last     time.Time
+	until    time.Time
+}
+
+// <summary>: 接続元と部屋の組毎の合言葉の検証の失敗記録
+type passGuardMap struct {
+	m  map[string]passGuard
+	mu sync.Mutex
+}
+
+var (
+	// <summary>: 合言葉の検証の失敗記録
+	passGuards = &passGuardMap{
+		m: make(map[string]passGuard),
+	}
+)
+
+// <summary>: 合言葉をbcryptでハッシュ化します
+func hashPassphrase(pass string) (string, error) {
+	h, err := bcrypt.GenerateFromPassword([]byte(pass), passphraseCost)
+	if err != nil {
+		return "", err
+	}
+
+	return string(h), nil
+}
+
+// <summary>: 部屋に設定できる合言葉か確認します
+func isValidPassphrase(pass string) bool {
+	return len(pass) <= maxPassphraseBytes
+}
+
+// <summary>: 合言葉が部屋に設定されたものと一致するか検証します
+// <remark>: 合言葉が設定されていない部屋では常にtrueを返す
+func VerifyPassphrase(room models.RoomInfoSet, pass string) bool {
+	if room.PassHash == "" {
+		return true
+	}
+
+	return bcrypt.CompareHashAndPassword([]byte(room.PassHash), []byte(pass)) == nil
+}
+
+// <summary>: 入室先の部屋の合言葉を検証し、検証したハッシュ値をリクエストに記録します
+// <remark>: 負荷の高い検証を接続毎の受信処理で行い、部屋のアクターではハッシュ値の一致のみを確認する
+func verifyEntry(pc PlayerConn, req *models.WsRequest) (models.ErrorMessage, bool) {
+	room, ok := RoomPool.Get(req.RoomId)
+
+	// 部屋が存在しないエラーは、部屋への受け渡し時に返す
+	if !ok {
+		return models.ErrorMessage{}, true
+	}
+
+	if errMsg, ok := CheckPassphrase(pc.ClientIP, req.RoomId, room, req.Passphrase); !ok {
+		return errMsg, false
+	}
+
+	req.VerifiedHash = room.PassHash
+
+	return models.ErrorMessage{}, true
+}
+
+// <summary>: 作成する部屋の合言葉をハッシュ化し、平文の合言葉に替えてリクエストに記録します
+// <remark>: 負荷の高いハッシュ化を接続毎の受信処理で行い、ロビーではハッシュ値を保持するのみとする
+func hashEntry(req *models.WsRequest, logp logParams) (models.ErrorMessage, bool) {
+	// 合言葉が長すぎればエラー
+	if !isValidPassphrase(req.Passphrase) {
+		return models.ErrInvalidPassphrase, false
+	}
+
+	if req.Passphrase == "" {
+		return models.ErrorMessage{}, true
+	}
+
+	h, err := hashPassphrase(req.Passphrase)
+	if err != nil {
+		logp.IsProcError = true
+		logp.log(fmt.Sprintf("合言葉のハッシュ化に失敗しました: %s", err))
+
+		return models.ErrInvalidPassphrase, false
+	}
+
+	req.PassHash = h
+	req.Passphrase = ""
+
+	return models.ErrorMessage{}, true
+}
+
+// <summary>: 失敗回数を制限しながら、合言葉が部屋に設定されたものと一致するか検証します
+// <remark>: 接続元と部屋の組毎に失敗を数え、連続して失敗していれば検証せずにエラーを返す
+func CheckPassphrase(clientIP string, roomid string, room models.RoomInfoSet, pass string) (models.ErrorMessage, bool) {
+	if room.PassHash == "" {
+		return models.ErrorMessage{}, true
+	}
+
+	key := clientIP + "|" + roomid
+
+	// 合言葉が必要な部屋で合言葉がなければエラー
+	if pass == "" {
+		return models.ErrPassphraseRequired, false
+	}
+
+	// 連続して失敗している間は、検証せずにエラー
+	if !passGuards.allow(key) {
+		return models.ErrTooManyAttempts, false
+	}
+
+	if !VerifyPassphrase(room, pass) {
+		passGuards.fail(key)
+		return models.ErrWrongPassphrase, false
+	}
+
+	passGuards.reset(key)
+
+	return models.ErrorMessage{}, true
+}
+
+// <summary>: 合言葉の検証を行ってよいか確認します
+func (g *passGuardMap) allow(key string) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return !time.Now().Before(g.m[key].until)
+}
+
+// <summary>: 合言葉の検証の失敗を記録します
+// <remark>: 連続して失敗すれば、一定時間検証を拒否する
+func (g *passGuardMap) fail(key string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if len(g.m) >= passGuardPrune {
+		g.prune()
+	}
+
+	now := time.Now()
+	pg := g.m[key]
+
+	// 前回の失敗から時間が経過していれば、失敗回数を数え直す
+	if now.Sub(pg.last) >= passLockout {
+		pg.failures = 0
+	}
+
+	pg.failures++
+	pg.last = now
+
+	if pg.failures >= passFailureLimit {
+		pg.failures = 0
+		pg.until = now.Add(passLockout)
+	}
+
+	g.m[key] = pg
+}
+
+// <summary>: 合言葉の検証の失敗記録を削除します
+func (g *passGuardMap) reset(key string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	delete(g.m, key)
+}
+
+// <summary>: 有効期限の切れた失敗記録を削除します
+// <remark>: 検証を拒否している間、もしくは失敗回数を数えている間の記録は残す
+// <remark>: 呼び出し元でロックを取得しておく
+func (g *passGuardMap) prune() {
+	now := time.Now()
+
+	for key, pg := range g.m {
+		if !now.Before(pg.until) && now.Sub(pg.last) >= passLockout {
+			delete(g.m, key)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package ws
+
+import (
+	"testing"
+
+	"bgtools-api/models"
+)
+
+func TestIsValidPoints(t *testing.T) {
+	cats := []models.ScoreCategory{
+		{Name: "点数", Min: 0, Max: 100, Weight: 1, IsTotal: true},
+		{Name: "借金", Min: -10, Max: 0, Weight: 1, IsTotal: true},
+	}
+
+	tests := []struct {
+		name   string
+		cats   []models.ScoreCategory
+		points []int
+		want   bool
+	}{
+		{"得点表がなければ全て受け付ける", nil, []int{1, -5, 999}, true},
+		{"得点表がなければ空も受け付ける", nil, []int{}, true},
+		{"範囲内", cats, []int{50, -3}, true},
+		{"範囲の境界", cats, []int{0, -10}, true},
+		{"上限を超える", cats, []int{101, 0}, false},
+		{"下限を下回る", cats, []int{0, -11}, false},
+		{"項目が足りない", cats, []int{50}, false},
+		{"項目が多い", cats, []int{50, 0, 1}, false},
+		{"空", cats, []int{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isValidPoints(tt.cats, tt.points); got != tt.want {
+				t.Errorf("isValidPoints(%v) = %v, want %v", tt.points, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package ws
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// 表示名の最大文字数
+	maxNameLength int = 16
+
+	// アバター（絵文字など）の最大文字数
+	maxAvatarLength int = 8
+)
+
+// <summary>: 表示名を検証し、前後の空白を取り除いた値を返します
+// <remark>: 空文字は未設定として許容する
+func normalizeName(name string) (string, bool) {
+	name = strings.TrimSpace(name)
+
+	if name == "" {
+		return "", true
+	}
+
+	if maxNameLength < utf8.RuneCountInString(name) {
+		return "", false
+	}
+
+	return name, isPrintable(name)
+}
+
+// <summary>: アバターを検証し、前後の空白を取り除いた値を返します
+// <remark>: 空文字は未設定として許容する
+func normalizeAvatar(avatar string) (string, bool) {
+	avatar = strings.TrimSpace(avatar)
+
+	if maxAvatarLength < utf8.RuneCountInString(avatar) {
+		return "", false
+	}
+
+	return avatar, isPrintable(avatar)
+}
+
+// <summary>: 制御文字や不正なUTF-8を含まないか確認します
+func isPrintable(s string) bool {
+	if !utf8.ValidString(s) {
+		return false
+	}
+
+	for _, r := range s {
+		// 絵文字の結合に使われるZWJなどは許容する
+		if unicode.IsControl(r) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// <summary>: 部屋の中で表示名が既に使われているか確認します
+// <remark>: 大文字小文字は区別せず、exceptに指定した接続は対象外とする
+func (a *roomActor) isNameTaken(name, except string) bool {
+	if name == "" {
+		return false
+	}
+
+	for _, p := range a.room.Players {
+		if p.ConnId != except && strings.EqualFold(p.Name, name) {
+			return true
+		}
+	}
+
+	return false
+}
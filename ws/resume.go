@@ -0,0 +1,98 @@
+package ws
+
+import (
+	"time"
+
+	"bgtools-api/models"
+
+	"github.com/gorilla/websocket"
+)
+
+var (
+	// <summary>: 切断後に席を保持しておく猶予期間
+	resumeGracePeriod time.Duration = defaultResumeGracePeriod
+)
+
+// <summary>: 切断された接続を処理します
+// <remark>: 入室中であれば猶予期間の間だけ席を保持する
+func dropConnection(id string, conn *websocket.Conn) {
+	pc, ok := PlayerPool.Get(id)
+
+	// 既に別の接続へ引き継がれていれば何もしない
+	if !ok || pc.C != conn {
+		return
+	}
+
+	pc.close()
+
+	if pc.RoomId == "" {
+		PlayerPool.Delete(id)
+		return
+	}
+
+	timer := time.AfterFunc(resumeGracePeriod, func() {
+		expireSeat(id)
+	})
+
+	PlayerPool.Update(id, func(p *PlayerConn) bool {
+		p.C = nil
+		p.out = nil
+		p.expire = timer
+		return true
+	})
+
+	req := models.WsRequest{
+		Method: models.DISCONNECT.String(),
+		ConnId: id,
+		RoomId: pc.RoomId,
+	}
+
+	if !rooms.dispatch(pc.RoomId, req, actionDisconnect) {
+		timer.Stop()
+		PlayerPool.Delete(id)
+	}
+}
+
+// <summary>: 猶予期間を過ぎた席を解放します
+func expireSeat(id string) {
+	pc, ok := PlayerPool.Get(id)
+
+	// 再開済み、もしくは再接続済みであれば何もしない
+	if !ok || pc.C != nil {
+		return
+	}
+
+	logp := newLogParams(id)
+	logp.Method = models.DISCONNECT
+	logp.Prefix = "[expire]"
+	logp.log("猶予期間が過ぎたため、席を解放しました")
+
+	deleteConnection(id)
+}
+
+// <summary>: 切断されたプレイヤーの席を保持したまま、他のプレイヤーに通知します
+// <remark>: 観戦者は席を持たないため、そのまま削除する
+func actionDisconnect(a *roomActor, req models.WsRequest) {
+	if a.removeSpectator(req.ConnId) {
+		PlayerPool.Delete(req.ConnId)
+		a.notify("")
+		return
+	}
+
+	i := a.indexOf(req.ConnId)
+	if i < 0 {
+		return
+	}
+
+	a.room.Players[i].IsConnected = false
+	a.notify(req.ConnId)
+}
+
+// <summary>: 接続情報を部屋とプレイヤー情報プールから削除します
+func actionRemove(a *roomActor, req models.WsRequest) {
+	if a.removePlayer(req.ConnId) || a.removeSpectator(req.ConnId) {
+		a.notify("")
+	}
+
+	PlayerPool.Delete(req.ConnId)
+}
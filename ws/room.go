@@ -0,0 +1,672 @@
+package ws
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"bgtools-api/models"
+	"bgtools-api/score"
+)
+
+const (
+	// 部屋アクターが処理待ちとして溜められるメッセージ数
+	roomInboxSize int = 64
+
+	// 部屋毎に保持する得点の変更履歴の件数（超えれば古いものから取り除く）
+	// やり直し履歴は変更履歴から移したもののみのため、併せてこの件数に収まる
+	maxHistoryLength int = 1000
+)
+
+// <summary>: 部屋アクターへの受け渡し結果
+type dispatchResult int
+
+const (
+	// 部屋アクターに受け渡した
+	dispatched dispatchResult = iota
+
+	// 部屋が存在しない、もしくは既に閉じられている
+	roomMissing
+
+	// 部屋アクターの処理待ちが溢れている
+	roomBusy
+)
+
+// <summary>: 部屋アクターが処理するメッセージ
+type roomMessage struct {
+	req    models.WsRequest
+	action func(*roomActor, models.WsRequest)
+}
+
+// <summary>: 部屋毎にリクエストを直列に処理するアクター
+// <remark>: roomはアクターのgoroutineからのみ読み書きする
+// <remark>: inboxが溢れている間、ロビー以外からのメッセージはurgentで直接受け渡す
+// <remark>: finishingはプレイ記録の保存中にtrueとなる
+type roomActor struct {
+	id        string
+	room      models.RoomInfoSet
+	seq       int
+	offers    map[string]colorOffer
+	finishing bool
+	inbox     chan roomMessage
+	urgent    chan roomMessage
+	done      chan struct{}
+}
+
+// <summary>: 色の交換の申し込み
+// <remark>: 申し込んだ時点の双方の色を保持し、色が変わっていれば成立させない
+type colorOffer struct {
+	targetId  string
+	fromColor string
+	toColor   string
+}
+
+// <summary>: 稼働中の部屋アクターの格納庫
+type roomRegistry struct {
+	m  map[string]*roomActor
+	mu sync.Mutex
+}
+
+var (
+	// <summary>: 稼働中の部屋アクター
+	rooms = &roomRegistry{
+		m: make(map[string]*roomActor),
+	}
+
+	// <summary>: 操作のない部屋を閉じるまでの時間
+	roomIdleTimeout time.Duration = defaultRoomIdleTimeout
+)
+
+// <summary>: 部屋アクターを登録し、処理を開始します
+// <remark>: 開始時点の部屋の状態を返し、既に同じ部屋IDが存在していればfalseを返す
+func (g *roomRegistry) start(id string, room models.RoomInfoSet) (models.RoomResponse, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if _, exist := g.m[id]; exist {
+		return models.RoomResponse{}, false
+	}
+
+	a := &roomActor{
+		id:     id,
+		room:   cloneRoom(room),
+		seq:    lastSeq(room),
+		offers: make(map[string]colorOffer),
+		inbox:  make(chan roomMessage, roomInboxSize),
+		urgent: make(chan roomMessage),
+		done:   make(chan struct{}),
+	}
+
+	g.m[id] = a
+	a.calculate()
+	a.publish()
+
+	res := a.response()
+
+	go a.run()
+
+	return res, true
+}
+
+// <summary>: 部屋アクターが存在するか確認します
+func (g *roomRegistry) exists(id string) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	_, ok := g.m[id]
+	return ok
+}
+
+// <summary>: 部屋アクターにメッセージを渡します
+// <remark>: 部屋が存在しない、もしくは既に閉じられていればfalseを返す
+// <remark>: 処理待ちが溢れていれば受け取られるまで待つため、ロビーからはtryDispatchを使用する
+func (g *roomRegistry) dispatch(id string, req models.WsRequest,
+	action func(*roomActor, models.WsRequest)) bool {
+
+	switch g.tryDispatch(id, req, action) {
+	case dispatched:
+		return true
+
+	case roomMissing:
+		return false
+	}
+
+	g.mu.Lock()
+	a, ok := g.m[id]
+	g.mu.Unlock()
+
+	if !ok {
+		return false
+	}
+
+	// 溢れている間は、アクターが直接受け取るまで待つ
+	select {
+	case a.urgent <- roomMessage{req: req, action: action}:
+		return true
+
+	case <-a.done:
+		return false
+	}
+}
+
+// <summary>: 部屋アクターにメッセージを待たずに渡します
+// <remark>: ロビーが一つの部屋の処理を待たないよう、処理待ちが溢れていればroomBusyを返す
+// <remark>: 登録の解除と排他にし、閉じた部屋の処理待ちにメッセージが残らないようにする
+func (g *roomRegistry) tryDispatch(id string, req models.WsRequest,
+	action func(*roomActor, models.WsRequest)) dispatchResult {
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	a, ok := g.m[id]
+	if !ok {
+		return roomMissing
+	}
+
+	select {
+	case a.inbox <- roomMessage{req: req, action: action}:
+		return dispatched
+
+	default:
+		return roomBusy
+	}
+}
+
+// <summary>: 全ての部屋アクターが受け取り済みのメッセージを処理しきるまで待ちます
+// <remark>: 処理後の状態は部屋情報プールへ公開済みとなる
+func (g *roomRegistry) flush(ctx context.Context) error {
+	g.mu.Lock()
+	ids := make([]string, 0, len(g.m))
+
+	for id := range g.m {
+		ids = append(ids, id)
+	}
+	g.mu.Unlock()
+
+	for _, id := range ids {
+		done := make(chan struct{})
+
+		req := models.WsRequest{
+			Method: models.NONE.String(),
+			RoomId: id,
+		}
+
+		ok := g.dispatch(id, req, func(*roomActor, models.WsRequest) {
+			close(done)
+		})
+
+		// 既に閉じられた部屋は待たない
+		if !ok {
+			continue
+		}
+
+		select {
+		case <-done:
+
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return nil
+}
+
+// <summary>: 部屋アクターの登録を解除します
+func (g *roomRegistry) remove(id string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	delete(g.m, id)
+}
+
+// <summary>: 部屋宛てのメッセージを順に処理します
+// <remark>: 一定時間操作がなければ、全員を退室させて部屋を閉じる
+func (a *roomActor) run() {
+	timer := time.NewTimer(time.Until(a.room.LastActivity.Add(roomIdleTimeout)))
+	defer timer.Stop()
+
+	for {
+		var msg roomMessage
+
+		select {
+		case msg = <-a.inbox:
+		case msg = <-a.urgent:
+
+		case <-timer.C:
+			a.expire()
+			return
+		}
+
+		msg.action(a, msg.req)
+
+		// プレイヤーがいなくなれば、観戦者も退室させて部屋を閉じる
+		if len(a.room.Players) == 0 {
+			for _, s := range cloneRoom(a.room).Spectators {
+				a.eject(s.ConnId, "closed")
+			}
+
+			a.close()
+			return
+		}
+
+		// 切断の検知や内部処理はプレイヤーによる操作とみなさない
+		if m := models.ParseMethod(msg.req.Method); m != models.DISCONNECT && m != models.NONE {
+			a.room.LastActivity = time.Now()
+
+			if !timer.Stop() {
+				select {
+				case <-timer.C:
+				default:
+				}
+			}
+
+			timer.Reset(roomIdleTimeout)
+		}
+
+		a.publish()
+	}
+}
+
+// <summary>: 操作のなくなった部屋から全員を退室させ、部屋を閉じます
+func (a *roomActor) expire() {
+	logp := newLogParams("")
+	logp.Prefix = "[expire]"
+	logp.log(fmt.Sprintf("一定時間操作がないため、部屋を閉じました: %s", a.id))
+
+	room := cloneRoom(a.room)
+
+	for _, s := range room.Spectators {
+		a.eject(s.ConnId, "expired")
+	}
+
+	for _, p := range room.Players {
+		a.eject(p.ConnId, "expired")
+	}
+
+	a.close()
+}
+
+// <summary>: 部屋を閉じ、処理しきれなかったメッセージにエラーを返します
+// <remark>: 登録を解除した後は処理待ちにメッセージが積まれないため、残りを全て処理できる
+func (a *roomActor) close() {
+	rooms.remove(a.id)
+	RoomPool.Delete(a.id)
+	roomCodes.retire(a.id)
+	close(a.done)
+
+	for {
+		select {
+		case msg := <-a.inbox:
+			// 切断の後始末は部屋が閉じられていても行う
+			if models.ParseMethod(msg.req.Method) == models.DISCONNECT {
+				msg.action(a, msg.req)
+				continue
+			}
+
+			if pc, ok := PlayerPool.Get(msg.req.ConnId); ok {
+				logp := newLogParams(msg.req.ConnId)
+				logp.Prefix = fmt.Sprintf("<%s>", msg.req.Method)
+
+				pc.sendError(models.ErrRoomNotFound, logp)
+			}
+
+		default:
+			return
+		}
+	}
+}
+
+// <summary>: 部屋情報の複製を部屋情報プールへ公開します
+// <remark>: REST APIなど、アクター外からの参照は公開された複製に対して行う
+func (a *roomActor) publish() {
+	RoomPool.Set(a.id, cloneRoom(a.room))
+}
+
+// <summary>: 部屋の状態をRoomResponseとして取得します
+func (a *roomActor) response() models.RoomResponse {
+	data := a.room.GameData
+
+	return models.RoomResponse{
+		IsWait:   len(a.room.Players) < data.MinPlayers,
+		IsFull:   len(a.room.Players) >= data.MaxPlayers,
+		RoomId:   a.id,
+		RoomInfo: cloneRoom(a.room),
+	}
+}
+
+// <summary>: 部屋の得点と履歴をHistoryResponseとして取得します
+func (a *roomActor) history() models.HistoryResponse {
+	room := cloneRoom(a.room)
+
+	return models.HistoryResponse{
+		RoomId:  a.id,
+		GameId:  room.GameId,
+		Players: room.Players,
+		History: room.History,
+	}
+}
+
+// <summary>: プレイヤーの得点を更新し、履歴に記録します
+// <remark>: 履歴が上限を超えれば、古い変更から取り除く
+func (a *roomActor) recordPoints(i int, points []int) models.ScoreChange {
+	a.seq++
+
+	change := models.ScoreChange{
+		Seq:       a.seq,
+		PlayerId:  a.room.Players[i].PlayerId,
+		ChangedAt: time.Now(),
+		Previous:  a.room.Players[i].Points,
+		Current:   points,
+	}
+
+	a.setPoints(i, points)
+	a.room.History = append(a.room.History, change)
+
+	if n := len(a.room.History); n > maxHistoryLength {
+		a.room.History = a.room.History[n-maxHistoryLength:]
+	}
+
+	return change
+}
+
+// <summary>: プレイヤーの得点を更新し、合計点と順位を計算し直します
+func (a *roomActor) setPoints(i int, points []int) {
+	a.room.Players[i].Points = points
+	a.calculate()
+}
+
+// <summary>: ボードゲームの得点計算で、部屋の合計点と順位を計算します
+func (a *roomActor) calculate() {
+	cats := a.room.GameData.Categories
+	players := cloneRoom(a.room).Players
+	result := a.runCalculator(score.Lookup(a.room.GameId), cats, players)
+
+	totals := make(map[string]int, len(result.Ranking))
+	for _, r := range result.Ranking {
+		totals[r.PlayerId] = r.Total
+	}
+
+	for i, p := range a.room.Players {
+		a.room.Players[i].Total = totals[p.PlayerId]
+	}
+
+	a.room.Result = result
+}
+
+// <summary>: 得点計算を実行します
+// <remark>: 個別の得点計算で予期せぬエラーが発生すれば、部屋のアクターを止めずに標準の得点計算で計算し直す
+func (a *roomActor) runCalculator(c score.Calculator, cats []models.ScoreCategory, players []models.PlayerInfoSet) (result models.ScoreResult) {
+	defer func() {
+		if r := recover(); r != nil {
+			logp := newLogParams("")
+			logp.IsProcError = true
+			logp.log(fmt.Sprintf("得点計算で予期せぬエラーが発生しました: %s: %s", a.room.GameId, r))
+
+			result = score.Default.Calculate(cats, players)
+		}
+	}()
+
+	return c.Calculate(cats, players)
+}
+
+// <summary>: 部屋の現在の得点と順位をプレイ記録として取得します
+func (a *roomActor) record() models.PlayRecord {
+	rec := models.PlayRecord{
+		GameId:    a.room.GameId,
+		RoomId:    a.id,
+		StartedAt: a.room.CreatedAt,
+		PlayedAt:  time.Now(),
+		Players:   make([]models.PlayerRecord, 0, len(a.room.Players)),
+		IsHidden:  a.room.IsPrivate || a.room.IsLocked,
+	}
+
+	for _, r := range a.room.Result.Ranking {
+		i := a.indexOfPlayer(r.PlayerId)
+		if i < 0 {
+			continue
+		}
+
+		p := a.room.Players[i]
+
+		rec.Players = append(rec.Players, models.PlayerRecord{
+			PlayerId: p.PlayerId,
+			Name:     p.Name,
+			Avatar:   p.Avatar,
+			Color:    p.PlayerColor,
+			Points:   p.Points,
+			Total:    r.Total,
+			Rank:     r.Rank,
+			IsWinner: r.Rank == 1,
+		})
+	}
+
+	return rec
+}
+
+// <summary>: PlayerIdをもとに部屋にいるプレイヤーを検索します
+// <remark>: 見つからなければ-1を返す
+func (a *roomActor) indexOfPlayer(playerid string) int {
+	for i, p := range a.room.Players {
+		if p.PlayerId == playerid {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// <summary>: 得点の変更履歴とやり直し履歴から最大の連番を取得します
+// <remark>: 復元した部屋で連番が重複しないようにする
+func lastSeq(room models.RoomInfoSet) int {
+	seq := 0
+
+	for _, list := range [][]models.ScoreChange{room.History, room.Redo} {
+		for _, c := range list {
+			if seq < c.Seq {
+				seq = c.Seq
+			}
+		}
+	}
+
+	return seq
+}
+
+// <summary>: 得点の変更履歴から最後の変更を検索します
+// <remark>: playeridが空文字でなければ、そのプレイヤーの変更のみを対象とし、見つからなければ-1を返す
+func lastChange(list []models.ScoreChange, playerid string) int {
+	for i := len(list) - 1; i >= 0; i-- {
+		if playerid == "" || list[i].PlayerId == playerid {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// <summary>: 部屋にいるプレイヤーを検索します
+// <remark>: 見つからなければ-1を返す
+func (a *roomActor) indexOf(connid string) int {
+	for i, p := range a.room.Players {
+		if p.ConnId == connid {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// <summary>: 部屋にいる観戦者を検索します
+// <remark>: 見つからなければ-1を返す
+func (a *roomActor) indexOfSpectator(connid string) int {
+	for i, s := range a.room.Spectators {
+		if s.ConnId == connid {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// <summary>: 部屋から観戦者を削除します
+// <remark>: 削除の成否が取得できます
+func (a *roomActor) removeSpectator(connid string) bool {
+	i := a.indexOfSpectator(connid)
+	if i < 0 {
+		return false
+	}
+
+	a.room.Spectators = append(a.room.Spectators[:i], a.room.Spectators[i+1:]...)
+	PlayerPool.SetRoomId(connid, "")
+
+	return true
+}
+
+// <summary>: 部屋からプレイヤーを削除します
+// <remark>: 削除の成否が取得できます
+func (a *roomActor) removePlayer(connid string) bool {
+	i := a.indexOf(connid)
+	if i < 0 {
+		return false
+	}
+
+	removed := a.room.Players[i]
+
+	a.room.Players = append(a.room.Players[:i], a.room.Players[i+1:]...)
+	PlayerPool.SetRoomId(connid, "")
+	a.calculate()
+
+	// 退室したプレイヤーとの色の交換は成立させない
+	delete(a.offers, removed.PlayerId)
+
+	// ホストが退室すれば、最も早く入室したプレイヤーへ引き継ぐ
+	if removed.PlayerId == a.room.HostId && len(a.room.Players) > 0 {
+		a.room.HostId = a.room.Players[0].PlayerId
+	}
+
+	return true
+}
+
+// <summary>: プレイヤー、もしくは観戦者を部屋から退室させ、本人にEJECTを送信します
+func (a *roomActor) eject(connid, reason string) {
+	if !a.removePlayer(connid) && !a.removeSpectator(connid) {
+		return
+	}
+
+	pc, ok := PlayerPool.Get(connid)
+	if !ok {
+		return
+	}
+
+	logp := newLogParams(connid)
+	logp.Method = models.EJECT
+	logp.Prefix = fmt.Sprintf("<%s>", models.EJECT.String())
+
+	res := models.WsResponse{
+		Method: models.EJECT.String(),
+		Params: models.EjectResponse{
+			RoomId: a.id,
+			Reason: reason,
+		},
+	}
+
+	pc.sendJson(res, logp)
+}
+
+// <summary>: fromからtoへの色の交換の申し込みが有効か確認します
+// <remark>: 申し込み後にどちらかの色が変わっていれば無効とする
+func (a *roomActor) isOffered(from, to models.PlayerInfoSet) bool {
+	o, ok := a.offers[from.PlayerId]
+
+	return ok && o.targetId == to.PlayerId &&
+		o.fromColor == from.PlayerColor && o.toColor == to.PlayerColor
+}
+
+// <summary>: 色の交換の申し込みを相手のプレイヤーに送信します
+func (a *roomActor) offerRecolor(connid string, from models.PlayerInfoSet) {
+	pc, ok := PlayerPool.Get(connid)
+	if !ok {
+		return
+	}
+
+	o := a.offers[from.PlayerId]
+
+	logp := newLogParams(connid)
+	logp.Method = models.RECOLOR
+	logp.Prefix = fmt.Sprintf("<%s>", models.RECOLOR.String())
+
+	res := models.WsResponse{
+		Method: models.RECOLOR.String(),
+		Params: models.RecolorOffer{
+			RoomId:    a.id,
+			FromId:    from.PlayerId,
+			FromColor: o.fromColor,
+			ToColor:   o.toColor,
+		},
+	}
+
+	pc.sendJson(res, logp)
+}
+
+// <summary>: 部屋にいる他のプレイヤーに部屋の状態を通知します
+// <remark>: exceptに指定した接続には通知しない
+func (a *roomActor) notify(except string) {
+	res := models.WsResponse{
+		Method: models.NOTIFY.String(),
+		Params: a.response(),
+	}
+
+	a.send(res, models.NOTIFY, except)
+}
+
+// <summary>: 部屋にいる他のプレイヤーと観戦者にメッセージを送信します
+// <remark>: exceptに指定した接続には送信しない
+func (a *roomActor) send(res models.WsResponse, method models.Method, except string) {
+	conns := make([]string, 0, len(a.room.Players)+len(a.room.Spectators))
+
+	for _, p := range a.room.Players {
+		conns = append(conns, p.ConnId)
+	}
+
+	for _, s := range a.room.Spectators {
+		conns = append(conns, s.ConnId)
+	}
+
+	for _, id := range conns {
+		if id == except {
+			continue
+		}
+
+		pc, ex := PlayerPool.Get(id)
+		if !ex {
+			continue
+		}
+
+		logp := newLogParams(id)
+		logp.Method = method
+		logp.Prefix = fmt.Sprintf("<%s>", method.String())
+
+		pc.sendJson(res, logp)
+	}
+}
+
+// <summary>: 部屋情報を複製します
+func cloneRoom(room models.RoomInfoSet) models.RoomInfoSet {
+	players := make([]models.PlayerInfoSet, len(room.Players))
+	copy(players, room.Players)
+
+	history := make([]models.ScoreChange, len(room.History))
+	copy(history, room.History)
+
+	redo := make([]models.ScoreChange, len(room.Redo))
+	copy(redo, room.Redo)
+
+	spectators := make([]models.SpectatorInfoSet, len(room.Spectators))
+	copy(spectators, room.Spectators)
+
+	room.Players = players
+	room.Spectators = spectators
+	room.History = history
+	room.Redo = redo
+	return room
+}
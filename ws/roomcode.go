@@ -0,0 +1,143 @@
+package ws
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// サーバで払い出す部屋IDの文字列長
+	roomCodeLength int = 6
+
+	// 部屋IDが衝突した際に払い出しを再試行する回数
+	roomCodeRetries int = 10
+
+	// 利用者が指定できる部屋IDの最大文字列長（プレイ記録の部屋IDの列長）
+	maxRoomIdLength int = 16
+)
+
+// <summary>: サーバで払い出した部屋IDの格納庫
+// <remark>: 値は再利用可能になる時刻で、部屋が使用中の間はゼロ値となる
+type roomCodeMap struct {
+	m  map[string]time.Time
+	mu sync.Mutex
+}
+
+var (
+	// <summary>: 部屋が閉じられた後、部屋IDを再利用しない期間
+	roomCodeRetention time.Duration = defaultRoomCodeRetention
+
+	// <summary>: サーバで払い出した部屋ID
+	roomCodes = &roomCodeMap{
+		m: make(map[string]time.Time),
+	}
+)
+
+// <summary>: 部屋IDを予約します
+// <remark>: 使用中、もしくは再利用できない期間であればfalseを返す
+func (c *roomCodeMap) reserve(code string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+
+	for k, v := range c.m {
+		if !v.IsZero() && v.Before(now) {
+			delete(c.m, k)
+		}
+	}
+
+	if _, used := c.m[code]; used {
+		return false
+	}
+
+	c.m[code] = time.Time{}
+	return true
+}
+
+// <summary>: 部屋IDの予約を取り消します
+func (c *roomCodeMap) release(code string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.m, code)
+}
+
+// <summary>: 部屋が閉じられた部屋IDを、一定期間再利用できないようにします
+// <remark>: サーバで払い出した部屋IDでなければ何もしない
+func (c *roomCodeMap) retire(code string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.m[code]; ok {
+		c.m[code] = time.Now().Add(roomCodeRetention)
+	}
+}
+
+// <summary>: 推測しにくく、読み間違えにくい部屋IDを払い出します
+// <remark>: Hashidsと同じ紛らわしい文字を除いた文字列を使用する
+func newRoomCode() (string, error) {
+	for i := 0; i < roomCodeRetries; i++ {
+		code, err := randomCode(roomCodeLength)
+		if err != nil {
+			return "", err
+		}
+
+		if !roomCodes.reserve(code) {
+			continue
+		}
+
+		// 利用者が指定した部屋IDと衝突していれば再試行
+		if rooms.exists(code) {
+			roomCodes.release(code)
+			continue
+		}
+
+		return code, nil
+	}
+
+	return "", errors.New("部屋IDの払い出しに失敗しました")
+}
+
+// <summary>: 利用者が指定した部屋IDが使用できるか確認します
+// <remark>: 英数字と"-"、"_"のみを使用でき、プレイ記録に保存できる長さまでとする
+func isValidRoomId(id string) bool {
+	if id == "" || maxRoomIdLength < len(id) {
+		return false
+	}
+
+	for _, r := range id {
+		switch {
+		case 'a' <= r && r <= 'z', 'A' <= r && r <= 'Z', '0' <= r && r <= '9':
+		case r == '-', r == '_':
+
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+// <summary>: alphabetからランダムな文字列を生成します
+func randomCode(length int) (string, error) {
+	var result strings.Builder
+	result.Grow(length)
+
+	max := big.NewInt(int64(len(alphabet)))
+
+	for i := 0; i < length; i++ {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+
+		result.WriteByte(alphabet[n.Int64()])
+	}
+
+	return result.String(), nil
+}
@@ -0,0 +1,52 @@
+package ws
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestIsValidRoomId(t *testing.T) {
+	tests := []struct {
+		name string
+		id   string
+		want bool
+	}{
+		{"英数字", "Room42", true},
+		{"ハイフンとアンダースコア", "my-room_01", true},
+		{"最大長", strings.Repeat("a", maxRoomIdLength), true},
+		{"空", "", false},
+		{"最大長を超える", strings.Repeat("a", maxRoomIdLength+1), false},
+		{"空白", "my room", false},
+		{"記号", "room!", false},
+		{"区切り文字", "room|1", false},
+		{"全角英数字", "ｒｏｏｍ", false},
+		{"日本語", "部屋", false},
+		{"制御文字", "room\n", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isValidRoomId(tt.id); got != tt.want {
+				t.Errorf("isValidRoomId(%q) = %v, want %v", tt.id, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRandomCode(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		code, err := randomCode(roomCodeLength)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(code) != roomCodeLength || strings.Trim(code, alphabet) != "" {
+			t.Fatalf("randomCode() = %q", code)
+		}
+
+		// 払い出した部屋IDは、利用者が指定した場合と同じ検証を通る
+		if !isValidRoomId(code) {
+			t.Fatalf("isValidRoomId(%q) = false", code)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package ws
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"bgtools-api/models"
+
+	"github.com/gorilla/websocket"
+)
+
+var (
+	// <summary>: サーバの停止処理中であれば1
+	shuttingDown int32 = 0
+
+	// <summary>: 受信中のgoroutine
+	readers sync.WaitGroup
+
+	// <summary>: 停止処理の開始と受信中のgoroutineの登録を排他にするためのロック
+	readersMu sync.Mutex
+)
+
+// <summary>: サーバの停止処理中か確認します
+func IsShuttingDown() bool {
+	return atomic.LoadInt32(&shuttingDown) == 1
+}
+
+// <summary>: 受信中のgoroutineとして登録します
+// <remark>: 停止処理が始まっていれば登録せずにfalseを返す
+func addReader() bool {
+	readersMu.Lock()
+	defer readersMu.Unlock()
+
+	if IsShuttingDown() {
+		return false
+	}
+
+	readers.Add(1)
+	return true
+}
+
+// <summary>: 全ての接続へ停止を通知し、受信済みのリクエストを処理しきってから戻ります
+// <remark>: 以降の接続は受け付けず、ctxの期限を過ぎれば処理の途中でも戻る
+func Shutdown(ctx context.Context) error {
+	// 以降は受信中のgoroutineが登録されないため、待ち合わせられる
+	readersMu.Lock()
+	atomic.StoreInt32(&shuttingDown, 1)
+	readersMu.Unlock()
+
+	conns := make(map[string]PlayerConn, PlayerPool.Count())
+
+	PlayerPool.Range(func(id string, pc PlayerConn) {
+		conns[id] = pc
+	})
+
+	for id, pc := range conns {
+		pc.goAway(id)
+	}
+
+	drained := make(chan struct{})
+
+	go func() {
+		readers.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	return rooms.flush(ctx)
+}
+
+// <summary>: 停止の通知とCloseフレームを送信し、接続を切断します
+func (pc PlayerConn) goAway(id string) {
+	// 切断中の席には送信しない
+	if pc.out == nil {
+		return
+	}
+
+	logp := newLogParams(id)
+	logp.Method = models.SHUTDOWN
+	logp.Prefix = fmt.Sprintf("<%s>", models.SHUTDOWN.String())
+
+	res := models.WsResponse{
+		Method: models.SHUTDOWN.String(),
+		Params: models.ShutdownResponse{
+			Reason:    "going away",
+			CanResume: pc.RoomId != "",
+		},
+	}
+
+	pc.sendJson(res, logp)
+
+	bye := outbound{logp: logp, closeCode: websocket.CloseGoingAway}
+
+	if !pc.out.enqueue(bye) {
+		pc.out.close()
+	}
+}
@@ -0,0 +1,240 @@
+package ws
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"bgtools-api/models"
+)
+
+// <summary>: 再起動を跨いで部屋を引き継ぐための退避データ
+type snapshot struct {
+	SavedAt    time.Time      `json:"saved_at"`
+	PlayerSalt string         `json:"player_salt"`
+	PlayerSeq  int64          `json:"player_seq"`
+	Rooms      []roomSnapshot `json:"rooms"`
+}
+
+// <summary>: 部屋毎の退避データ
+// <remark>: RoomInfoSetでJSONに出力しない項目も併せて退避する
+type roomSnapshot struct {
+	RoomId   string               `json:"room_id"`
+	Room     models.RoomInfoSet   `json:"room"`
+	GameData models.BgPartialData `json:"game_data"`
+	PassHash string               `json:"pass_hash"`
+	History  []models.ScoreChange `json:"history"`
+	Redo     []models.ScoreChange `json:"redo"`
+	Seats    []seatSnapshot       `json:"seats"`
+}
+
+// <summary>: 席毎の退避データ
+type seatSnapshot struct {
+	PlayerId    string `json:"player_id"`
+	ClientIP    string `json:"client_ip"`
+	ResumeToken string `json:"resume_token"`
+}
+
+// <summary>: 稼働中の部屋と得点、席の再開用トークンをファイルへ退避します
+// <remark>: 再開用トークンを含むため、所有者のみが読み書きできるファイルとする
+func SaveSnapshot(path string) error {
+	snap := snapshot{
+		SavedAt:    time.Now(),
+		PlayerSalt: playerSalt,
+		PlayerSeq:  atomic.LoadInt64(&playerSeq),
+		Rooms:      make([]roomSnapshot, 0, RoomPool.Count()),
+	}
+
+	RoomPool.Range(func(id string, room models.RoomInfoSet) {
+		rs := roomSnapshot{
+			RoomId:   id,
+			Room:     room,
+			GameData: room.GameData,
+			PassHash: room.PassHash,
+			History:  room.History,
+			Redo:     room.Redo,
+			Seats:    make([]seatSnapshot, 0, len(room.Players)),
+		}
+
+		// 観戦者は席を持たないため退避しない
+		rs.Room.Spectators = []models.SpectatorInfoSet{}
+
+		for _, p := range room.Players {
+			pc, ok := PlayerPool.Get(p.ConnId)
+			if !ok {
+				continue
+			}
+
+			rs.Seats = append(rs.Seats, seatSnapshot{
+				PlayerId:    p.PlayerId,
+				ClientIP:    pc.ClientIP,
+				ResumeToken: pc.ResumeToken,
+			})
+		}
+
+		snap.Rooms = append(snap.Rooms, rs)
+	})
+
+	b, err := json.MarshalIndent(snap, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// 書き込み途中のファイルを読み込まないよう、一時ファイルから置き換える
+	tmp := path + ".tmp"
+
+	if err := ioutil.WriteFile(tmp, b, 0600); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmp, path); err != nil {
+		return err
+	}
+
+	logp := newLogParams("")
+	logp.Prefix = "[snapshot]"
+	logp.log(fmt.Sprintf("%d件の部屋を退避しました: %s", len(snap.Rooms), path))
+
+	return nil
+}
+
+// <summary>: 退避した部屋をファイルから復元します
+// <remark>: 全ての席は切断中として保持し、再開用トークンでRESUMEできる
+func RestoreSnapshot(path string) (int, error) {
+	b, err := ioutil.ReadFile(path)
+
+	// 退避データがなければ何もしない
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+
+	} else if err != nil {
+		return 0, err
+	}
+
+	var snap snapshot
+
+	if err := json.Unmarshal(b, &snap); err != nil {
+		return 0, err
+	}
+
+	restorePlayerIds(snap.PlayerSalt, snap.PlayerSeq)
+	count := 0
+
+	for _, rs := range snap.Rooms {
+		restored, err := restoreRoom(rs)
+		if err != nil {
+			return count, err
+		}
+
+		if restored {
+			count++
+		}
+	}
+
+	// 古い退避データを再度読み込まないよう、復元後に削除する
+	if err := os.Remove(path); err != nil {
+		return count, err
+	}
+
+	logp := newLogParams("")
+	logp.Prefix = "[snapshot]"
+	logp.log(fmt.Sprintf("%d件の部屋を復元しました: %s", count, path))
+
+	return count, nil
+}
+
+// <summary>: 退避データから部屋を復元します
+// <remark>: 再開できる席が一つもなければ、部屋は復元しない
+func restoreRoom(rs roomSnapshot) (bool, error) {
+	seats := make(map[string]seatSnapshot, len(rs.Seats))
+
+	for _, s := range rs.Seats {
+		seats[s.PlayerId] = s
+	}
+
+	room := rs.Room
+	room.GameData = rs.GameData
+
+	// 拡張を含まない退避データであれば、拡張なしとして扱う
+	if room.Expansions == nil {
+		room.Expansions = []string{}
+	}
+
+	// ボードゲーム情報を含まない退避データであれば、現在の情報を使用する
+	if room.GameData.MaxPlayers == 0 {
+		data, _, ok := combineGameData(models.BgScore(), room.GameId, room.Expansions)
+		if !ok {
+			data = models.BgScore()[room.GameId]
+		}
+
+		room.GameData = data
+	}
+	room.PassHash = rs.PassHash
+	room.History = rs.History
+	room.Redo = rs.Redo
+	room.Spectators = []models.SpectatorInfoSet{}
+	room.Players = make([]models.PlayerInfoSet, 0, len(rs.Room.Players))
+
+	conns := make(map[string]PlayerConn, len(rs.Seats))
+	hosted := false
+
+	for _, p := range rs.Room.Players {
+		s, ok := seats[p.PlayerId]
+		if !ok || s.ResumeToken == "" {
+			continue
+		}
+
+		connid, err := newConnToken()
+		if err != nil {
+			return false, err
+		}
+
+		p.ConnId = connid
+		p.IsConnected = false
+		room.Players = append(room.Players, p)
+
+		if p.PlayerId == room.HostId {
+			hosted = true
+		}
+
+		conns[connid] = PlayerConn{
+			PlayerId:    s.PlayerId,
+			ClientIP:    s.ClientIP,
+			RoomId:      rs.RoomId,
+			ResumeToken: s.ResumeToken,
+			expire: time.AfterFunc(resumeGracePeriod, func() {
+				expireSeat(connid)
+			}),
+		}
+	}
+
+	if len(room.Players) == 0 {
+		return false, nil
+	}
+
+	// ホストの席が復元できなければ、最も早く入室したプレイヤーへ引き継ぐ
+	if !hosted {
+		room.HostId = room.Players[0].PlayerId
+	}
+
+	for connid, pc := range conns {
+		PlayerPool.Set(connid, pc)
+	}
+
+	roomCodes.reserve(rs.RoomId)
+
+	if _, started := rooms.start(rs.RoomId, room); !started {
+		for connid, pc := range conns {
+			pc.expire.Stop()
+			PlayerPool.Delete(connid)
+		}
+
+		return false, nil
+	}
+
+	return true, nil
+}
@@ -0,0 +1,132 @@
+package ws
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"bgtools-api/models"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// 送信待ちキューの長さ
+	sendQueueSize int = 32
+
+	// 1メッセージの書き込みに許容する時間
+	writeWait time.Duration = 10 * time.Second
+)
+
+// <summary>: 送信待ちのメッセージ
+// <remark>: closeCodeが指定されていれば、Closeフレームを送信して切断する
+type outbound struct {
+	res       models.WsResponse
+	logp      logParams
+	closeCode int
+}
+
+// <summary>: 接続毎の送信キュー
+// <remark>: websocket.Connへの書き込みは専用のgoroutineのみが行う
+type sendQueue struct {
+	ch   chan outbound
+	done chan struct{}
+	once sync.Once
+}
+
+// <summary>: 送信キューを生成し、書き込み用のgoroutineを開始します
+func newSendQueue(conn *websocket.Conn) *sendQueue {
+	q := &sendQueue{
+		ch:   make(chan outbound, sendQueueSize),
+		done: make(chan struct{}),
+	}
+
+	go q.writeLoop(conn)
+
+	return q
+}
+
+// <summary>: メッセージを送信キューに積みます
+// <remark>: キューが満杯、もしくは閉じられていればfalseを返す
+func (q *sendQueue) enqueue(o outbound) bool {
+	select {
+	case <-q.done:
+		return false
+
+	default:
+	}
+
+	select {
+	case q.ch <- o:
+		return true
+
+	default:
+		return false
+	}
+}
+
+// <summary>: 送信キューを閉じ、接続を切断します
+func (q *sendQueue) close() {
+	q.once.Do(func() {
+		close(q.done)
+	})
+}
+
+// <summary>: 送信キューが閉じられているか確認します
+func (q *sendQueue) isClosed() bool {
+	select {
+	case <-q.done:
+		return true
+
+	default:
+		return false
+	}
+}
+
+// <summary>: 送信キューのメッセージを順に書き込みます
+// <remark>: 一定間隔でPingも送信し、応答の有無は受信側の期限で判定する
+func (q *sendQueue) writeLoop(conn *websocket.Conn) {
+	ticker := time.NewTicker(pingPeriod())
+
+	defer func() {
+		ticker.Stop()
+		conn.Close()
+	}()
+
+	for {
+		select {
+		case o := <-q.ch:
+			conn.SetWriteDeadline(time.Now().Add(writeWait))
+
+			if o.closeCode != 0 {
+				msg := websocket.FormatCloseMessage(o.closeCode, "")
+				conn.WriteMessage(websocket.CloseMessage, msg)
+
+				q.close()
+				return
+			}
+
+			if err := conn.WriteJSON(o.res); err != nil {
+				o.logp.IsProcError = true
+				o.logp.log(fmt.Sprintf("メッセージの送信に失敗しました: %s", err))
+
+				q.close()
+				return
+			}
+
+			// 応答には接続トークンや再開用トークンが含まれるため、メソッドのみを出力する
+			o.logp.log(fmt.Sprintf("送信完了: %s", o.res.Method))
+
+		case <-ticker.C:
+			conn.SetWriteDeadline(time.Now().Add(writeWait))
+
+			if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				q.close()
+				return
+			}
+
+		case <-q.done:
+			return
+		}
+	}
+}
@@ -1,8 +1,10 @@
 package ws
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"time"
 
 	"bgtools-api/models"
 
@@ -10,9 +12,16 @@ import (
 )
 
 // <summary>: プレイヤーの接続情報をまとめた構造体
+// <remark>: 切断後に席を保持している間、Cはnilとなる
 type PlayerConn struct {
-	C      *websocket.Conn
-	RoomId string
+	C           *websocket.Conn
+	PlayerId    string
+	ClientIP    string
+	RoomId      string
+	ResumeToken string
+
+	out    *sendQueue
+	expire *time.Timer
 }
 
 var (
@@ -31,18 +40,43 @@ var (
 	// <summary>: プレイヤーの接続情報プール
 	PlayerPool = NewPlayerMap()
 
-	// <summary>: 部屋情報プール
+	// <summary>: 部屋情報プール（各部屋のアクターが公開する複製）
 	RoomPool = NewRoomMap()
 )
 
 // <summary>: WebSocket接続時に行われる動作
 func EntryPoint(w http.ResponseWriter, r *http.Request) {
-	connid, err := getConnId(r.RemoteAddr)
-	logp := newLogParams(connid)
+	logp := newLogParams("")
+	logp.ClientIP = remoteIp(r.RemoteAddr)
 
+	// 停止処理中であれば新たな接続は受け付けない
+	if IsShuttingDown() {
+		http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+		return
+	}
+
+	connid, err := newConnToken()
+	if err != nil {
+		logp.IsProcError = true
+		logp.log(fmt.Sprintf("接続トークンの生成に失敗しました: %s", err))
+
+		return
+	}
+
+	playerid, err := newPlayerId()
+	if err != nil {
+		logp.IsProcError = true
+		logp.log(fmt.Sprintf("PlayerIdの生成に失敗しました: %s", err))
+
+		return
+	}
+
+	logp.PlayerId = playerid
+
+	resume, err := newConnToken()
 	if err != nil {
 		logp.IsProcError = true
-		logp.log(fmt.Sprintf("不正な接続元からのアクセスです: %s", err))
+		logp.log(fmt.Sprintf("再開用トークンの生成に失敗しました: %s", err))
 
 		return
 	}
@@ -56,46 +90,93 @@ func EntryPoint(w http.ResponseWriter, r *http.Request) {
 	}
 
 	pconn := PlayerConn{
-		C:      conn,
-		RoomId: "",
+		C:           conn,
+		PlayerId:    playerid,
+		ClientIP:    logp.ClientIP,
+		RoomId:      "",
+		ResumeToken: resume,
+		out:         newSendQueue(conn),
 	}
 	PlayerPool.Set(connid, pconn)
 
-	logp.ConnId = connid
 	logp.Method = models.CONNECT
 	logp.Prefix = fmt.Sprintf("<%s>", models.CONNECT.String())
 
 	res := models.WsResponse{
 		Method: models.CONNECT.String(),
 		Params: models.ConnectResponse{
-			ConnId: connid,
+			ConnId:      connid,
+			PlayerId:    playerid,
+			ResumeToken: resume,
 		},
 	}
 
 	pconn.sendJson(res, logp)
 
-	go readRequests(connid, pconn)
+	// 登録前に停止処理が始まっていれば、停止処理では待ち合わせずにすぐに切断する
+	tracked := addReader()
+
+	go func() {
+		if tracked {
+			defer readers.Done()
+		}
+
+		readRequests(connid, pconn)
+	}()
+
+	if !tracked {
+		pconn.goAway(connid)
+	}
 }
 
 // <summary>: WebSocketでのリクエストを待ち受けます
+// <remark>: ロビーとして部屋の生成と振り分けのみを行い、部屋毎の処理は各アクターが行う
 func ServeRequest() {
 	for {
 		// メッセージが入るまで、ここでブロック
 		e := <-chWsReq
 		var action func(models.WsRequest)
+		var roomAction func(*roomActor, models.WsRequest)
 
 		switch models.ParseMethod(e.Method) {
 		case models.CREATE:
 			action = actionCreate
 
+		case models.RESUME:
+			action = actionResume
+
 		case models.JOIN:
-			action = actionJoin
+			roomAction = actionJoin
+
+		case models.WATCH:
+			roomAction = actionWatch
 
 		case models.LEAVE:
-			action = actionLeave
+			roomAction = actionLeave
+
+		case models.EJECT:
+			roomAction = actionEject
+
+		case models.RENAME:
+			roomAction = actionRename
+
+		case models.RECOLOR:
+			roomAction = actionRecolor
+
+		case models.FINISH:
+			roomAction = actionFinish
 
 		case models.BROADCAST:
-			action = actionBroadcast
+			roomAction = actionBroadcast
+
+		case models.UNDO:
+			roomAction = actionUndo
+
+		case models.REDO:
+			roomAction = actionRedo
+
+		case models.HISTORY:
+			roomAction = actionHistory
 
 		default:
 			action = actionNone
@@ -103,6 +184,9 @@ func ServeRequest() {
 
 		if action != nil {
 			action(e)
+
+		} else if roomAction != nil {
+			dispatchToRoom(e, roomAction)
 		}
 	}
 }
@@ -114,124 +198,93 @@ func readRequests(id string, pc PlayerConn) {
 			elogp := newLogParams(id)
 			elogp.IsProcError = true
 
+			pc.close()
 			deleteConnection(id)
 			elogp.log(fmt.Sprintf("予期せぬエラーが発生しました: %s", r))
 		}
 	}()
 
-	var req models.WsRequest
+	extendReadDeadline(pc.C)
+
+	// Pongを受信すれば、受信の期限を延長する
+	pc.C.SetPongHandler(func(string) error {
+		return extendReadDeadline(pc.C)
+	})
 
 	for {
+		var req models.WsRequest
 		logp := newLogParams(id)
 
-		if err := pc.C.ReadJSON(&req); err == nil {
-			logp.Method = models.ParseMethod(req.Method)
-			logp.log(fmt.Sprintf("メッセージ受信: %+v", req))
+		_, msg, err := pc.C.ReadMessage()
 
-			if !isCorrectConnId(req.ConnId, pc.C.RemoteAddr().String()) {
-				pc.sendError(models.ErrIllegalConnId, logp)
-				continue
+		// 受信に失敗した接続は復旧しないため、席を保持して終了
+		if err != nil {
+			if cur, ok := PlayerPool.Get(id); ok && cur.C == pc.C {
+				logReadError(err, logp)
 			}
 
-			chWsReq <- req
-
-		} else {
-			// TODO: 他のCloseCodeのときはどうするか検討
-			// そもそもどういう状況でどんなCloseCodeになるか要調査
-			if websocket.IsCloseError(err, websocket.CloseNoStatusReceived) {
-				logp.Method = models.DISCONNECT
-				logp.Prefix = "[close-1005]"
-				logp.log("NoStatusReceived: 接続が切断されました")
+			dropConnection(id, pc.C)
+			break
+		}
 
-				if n := deleteConnection(id); n != "" {
-					notifyOtherPlayers(n)
-				}
+		extendReadDeadline(pc.C)
 
-				break
+		if err := json.Unmarshal(msg, &req); err != nil {
+			logp.IsProcError = true
+			logp.log(fmt.Sprintf("メッセージの解析に失敗しました: %s", err))
 
-			} else {
-				logp.IsProcError = true
-				logp.log(fmt.Sprintf("メッセージの受信に失敗しました: %s", err))
-			}
+			pc.sendError(models.ErrMalformedRequest, logp)
+			continue
 		}
-	}
-}
 
-// <summary>: 接続情報を削除します
-func deleteConnection(id string) (notify string) {
-	notify = deletePlayerInfo(id)
-	PlayerPool.Delete(id)
-
-	return
-}
+		logp.Method = models.ParseMethod(req.Method)
+		logp.log(fmt.Sprintf("メッセージ受信: %s", req))
 
-// <summary>: プレイヤー情報を部屋情報プールから削除します
-func deletePlayerInfo(id string) (notify string) {
-	notify = ""
-
-	pc, ok := PlayerPool.Get(id)
-	if !ok {
-		return
-	}
+		// 他の接続のConnIdを名乗っていればエラー
+		if req.ConnId != "" && req.ConnId != id {
+			pc.sendError(models.ErrIllegalConnId, logp)
+			continue
+		}
 
-	room, ok := RoomPool.Get(pc.RoomId)
-	if !ok {
-		return
-	}
+		// 接続とConnIdはサーバ側で紐付ける
+		req.ConnId = id
 
-	if len(room.Players) <= 1 {
-		RoomPool.Delete(pc.RoomId)
+		switch models.ParseMethod(req.Method) {
+		// 部屋の作成時の合言葉は、ロビーへ渡す前にハッシュ化する
+		case models.CREATE:
+			if errMsg, ok := hashEntry(&req, logp); !ok {
+				pc.sendError(errMsg, logp)
+				continue
+			}
 
-	} else {
-		for i, player := range room.Players {
-			if player.ConnId == id {
-				room.Players[i] = room.Players[len(room.Players)-1]
-				break
+		// 入室時の合言葉は、ロビーへ渡す前に検証する
+		case models.JOIN, models.WATCH:
+			if errMsg, ok := verifyEntry(pc, &req); !ok {
+				pc.sendError(errMsg, logp)
+				continue
 			}
 		}
 
-		room.Players = room.Players[:len(room.Players)-1]
-		RoomPool.Set(pc.RoomId, room)
-		notify = pc.RoomId
+		chWsReq <- req
 	}
-
-	PlayerPool.SetRoomId(id, "")
-	return
 }
 
-// <summary>: 部屋にいる他のプレイヤーに通知します
-func notifyOtherPlayers(roomid string) {
-	if roomid == "" {
-		return
-	}
-
-	room, exist := RoomPool.Get(roomid)
-	if !exist {
+// <summary>: 接続情報を削除します
+// <remark>: 入室中であれば部屋のアクターを経由して削除する
+func deleteConnection(id string) {
+	pc, ok := PlayerPool.Get(id)
+	if !ok {
 		return
 	}
 
-	min := models.BgScore[room.GameId].MinPlayers
-
-	res := models.WsResponse{
-		Method: models.NOTIFY.String(),
-		Params: models.RoomResponse{
-			IsWait:   len(room.Players) < min,
-			RoomId:   roomid,
-			RoomInfo: room,
-		},
+	req := models.WsRequest{
+		Method: models.DISCONNECT.String(),
+		ConnId: id,
+		RoomId: pc.RoomId,
 	}
 
-	for _, p := range room.Players {
-		pc, ex := PlayerPool.Get(p.ConnId)
-		if !ex {
-			continue
-		}
-
-		logp := newLogParams(p.ConnId)
-		logp.Method = models.NOTIFY
-		logp.Prefix = fmt.Sprintf("<%s>", models.NOTIFY.String())
-
-		pc.sendJson(res, logp)
+	if pc.RoomId == "" || !rooms.dispatch(pc.RoomId, req, actionRemove) {
+		PlayerPool.Delete(id)
 	}
 }
 
@@ -246,14 +299,30 @@ func (pc PlayerConn) sendError(err models.ErrorMessage, logp logParams) {
 	pc.sendJson(res, logp)
 }
 
-// <summary>: JSONデータを送信します
+// <summary>: JSONデータを送信キューに積みます
+// <remark>: キューが溢れるほど受信の遅い接続は切断する
 func (pc PlayerConn) sendJson(res models.WsResponse, logp logParams) {
-	if err := pc.C.WriteJSON(res); err == nil {
-		logp.log(fmt.Sprintf("送信完了: %+v", res))
+	// 切断中の席、もしくは切断処理中の接続には送信しない
+	if pc.out == nil || pc.out.isClosed() {
+		return
+	}
+
+	if ok := pc.out.enqueue(outbound{res: res, logp: logp}); !ok {
+		// 積む間に閉じられたのであれば、溢れではないため何もしない
+		if pc.out.isClosed() {
+			return
+		}
 
-	} else {
 		logp.IsProcError = true
-		e := fmt.Sprintf("メッセージの送信に失敗しました: %s", err)
-		logp.log(e)
+		logp.log("送信キューが溢れたため、接続を切断します")
+
+		pc.out.close()
+	}
+}
+
+// <summary>: 接続を切断します
+func (pc PlayerConn) close() {
+	if pc.out != nil {
+		pc.out.close()
 	}
 }